@@ -4,29 +4,107 @@ import (
 	"backend/config"
 	"backend/internal/delivery/controller"
 	"backend/internal/delivery/routes"
+	"backend/internal/event"
 	"backend/internal/middleware"
 	"backend/internal/repository"
 	"backend/internal/usecase"
 	"backend/pkg/jwt"
+	"backend/pkg/topk"
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
 	cfg := config.LoadConfig()
 
 	db := config.InitDB(cfg)
-	redisClient := config.InitRedis(cfg)
+	var redisClient *redis.Client
+	if cfg.Trending.Backend == "redis" || cfg.Events.Backend == "redis" {
+		redisClient = config.InitRedis(cfg)
+	}
 	cloud := config.InitCloudinary(cfg)
 
 	log.Println("DB:", db, "Redis:", redisClient, "Cloudinary:", cloud)
 
 	userRepo := repository.NewUserRepository(db)
 	postRepo := repository.NewPostRepositoryGorm(db)
-	likeRepo := repository.NewLikeRepositoryGorm(db)
 	commentRepo := repository.NewCommentRepositoryGorm(db)
+	outboxRepo := repository.NewOutboxRepositoryGorm(db)
+	snapshotRepo := repository.NewTrendingSnapshotRepositoryGorm(db)
+	likeFlagRepo := repository.NewLikeFlagRepositoryGorm(db)
+	tagRepo := repository.NewTagRepositoryGorm(db)
+	tx := repository.NewTransactorGorm(db)
+
+	ctx := context.Background()
+
+	likeRepo := repository.NewLikeRepositoryGorm(db)
+	switch cfg.Likes.WriteMode {
+	case "direct":
+	case "atomic", "buffered":
+		// Both modes decide toggles in Redis and move the Redis trending
+		// board from the toggle script.
+		if cfg.Trending.Backend != "redis" {
+			log.Fatalf("❌ LIKES_WRITE_MODE=%s requires TRENDING_BACKEND=redis", cfg.Likes.WriteMode)
+		}
+		buffered := cfg.Likes.WriteMode == "buffered"
+		redisLikes := repository.NewLikeRepositoryRedis(likeRepo, redisClient, buffered)
+		if buffered {
+			// Replay whatever a previous process left unflushed before the
+			// trending rebuild reads the likes table.
+			if err := redisLikes.Recover(ctx, cfg.Likes.FlushBatch); err != nil {
+				log.Fatalf("❌ Failed to replay buffered likes: %v", err)
+			}
+			go redisLikes.RunFlusher(ctx, cfg.Likes.FlushInterval, cfg.Likes.FlushBatch)
+		}
+		likeRepo = redisLikes
+	default:
+		log.Fatalf("❌ Unknown LIKES_WRITE_MODE %q (expected direct, atomic or buffered)", cfg.Likes.WriteMode)
+	}
+
+	var trendingStore repository.TrendingStore
+	var trendingFeed repository.TrendingFeed
+	var postLiveFeed repository.PostLiveFeed
+	var viewStore repository.ViewStore
+	switch cfg.Trending.Backend {
+	case "redis":
+		trendingStore = repository.NewTrendingStoreRedis(redisClient)
+		trendingFeed = repository.NewTrendingFeedRedis(redisClient, cfg.Trending.StreamHistory)
+		postLiveFeed = repository.NewPostLiveFeedRedis(redisClient)
+		viewStore = repository.NewViewStoreRedis(redisClient)
+	case "memory":
+		trendingStore = repository.NewTrendingStoreMemory()
+		trendingFeed = repository.NewTrendingFeedMemory(cfg.Trending.StreamHistory)
+		postLiveFeed = repository.NewPostLiveFeedMemory()
+		viewStore = repository.NewViewStoreMemory()
+	default:
+		log.Fatalf("❌ Unknown TRENDING_BACKEND %q (expected redis or memory)", cfg.Trending.Backend)
+	}
+
+	var bus event.Bus
+	switch cfg.Events.Backend {
+	case "sync":
+		bus = event.NewSyncBus()
+	case "redis":
+		bus = event.NewRedisBus(redisClient)
+	default:
+		log.Fatalf("❌ Unknown EVENTS_BACKEND %q (expected sync or redis)", cfg.Events.Backend)
+	}
+
+	// Usecases record events in the outbox inside their own transaction;
+	// the relay hands them to the bus.
+	outboxUC := usecase.NewOutboxUsecase(tx, outboxRepo, bus, usecase.OutboxOptions{
+		MaxAttempts: cfg.Events.OutboxMaxAttempts,
+		Backoff:     cfg.Events.OutboxBackoff,
+		MaxBackoff:  cfg.Events.OutboxMaxBackoff,
+		Retention:   cfg.Events.OutboxRetention,
+	})
 
 	jwtService := jwt.NewJWTService(
 		cfg.JWT.AccessSecret,
@@ -34,20 +112,201 @@ func main() {
 		"TrendSpire",
 	)
 
-	userUC := usecase.NewUserUsecase(userRepo, jwtService, 5*time.Second)
-	postUC := usecase.NewPostUsecase(postRepo)
-	likeUC := usecase.NewLikeUsecase(likeRepo)
-	commentUC := usecase.NewCommentUsecase(commentRepo)
+	userUC := usecase.NewUserUsecase(userRepo, jwtService, 5*time.Second, tx, outboxUC)
+	sketch, err := topk.New(cfg.Trending.SketchAlgo, cfg.Trending.SketchEpsilon, cfg.Trending.SketchDelta)
+	if err != nil {
+		log.Fatalf("❌ Failed to build trending sketch: %v", err)
+	}
+
+	viewUC := usecase.NewViewUsecase(viewStore, bus)
+	postSummaryUC := usecase.NewPostSummaryUsecase(likeRepo, userRepo, viewUC)
+	trendingUC := usecase.NewTrendingUsecase(trendingStore, postRepo, userRepo, likeRepo, commentRepo, likeFlagRepo, tagRepo, viewUC, postSummaryUC, sketch, usecase.TrendingOptions{
+		HalfLife:           cfg.Trending.HalfLife,
+		Gravity:            cfg.Trending.Gravity,
+		WindowCacheTTL:     cfg.Trending.WindowCacheTTL,
+		ScoredByLikeToggle: cfg.Likes.WriteMode != "direct",
+		Engagement:         engagementWeights(cfg.Trending.Engagement),
+		Rising: usecase.RisingOptions{
+			Recent:      cfg.Trending.Rising.Recent,
+			Baseline:    cfg.Trending.Rising.Baseline,
+			MinActivity: cfg.Trending.Rising.MinActivity,
+		},
+	})
+	go reloadWeightsOnHangup(ctx, trendingUC)
+	postUC := usecase.NewPostUsecase(postRepo, tagRepo, tx, outboxUC, usecase.PostOptions{Gravity: cfg.Trending.Gravity})
+
+	if cfg.Trending.RebuildOnStart {
+		if err := trendingUC.Rebuild(ctx); err != nil {
+			log.Fatalf("❌ Failed to rebuild trending scores: %v", err)
+		}
+	}
+	if cfg.Trending.DriftInterval > 0 {
+		go trendingUC.RunDriftDetector(ctx, cfg.Trending.DriftInterval, cfg.Trending.DriftSample)
+	}
+	snapshotUC := usecase.NewTrendingSnapshotUsecase(trendingUC, snapshotRepo, postRepo, postSummaryUC, usecase.TrendingSnapshotOptions{
+		K:         cfg.Trending.SnapshotK,
+		Interval:  cfg.Trending.SnapshotInterval,
+		Retention: cfg.Trending.SnapshotRetention,
+	})
+	if cfg.Trending.SnapshotInterval > 0 {
+		go snapshotUC.Run(ctx)
+	}
+	trendingStreamUC := usecase.NewTrendingStreamUsecase(trendingUC, trendingFeed, usecase.TrendingStreamOptions{
+		K:        cfg.Trending.StreamK,
+		Interval: cfg.Trending.StreamInterval,
+	})
+	if err := trendingStreamUC.Start(ctx); err != nil {
+		log.Fatalf("❌ Failed to start trending stream: %v", err)
+	}
+	postLiveUC := usecase.NewPostLiveUsecase(postLiveFeed, likeRepo, commentRepo)
+	if err := postLiveUC.Start(ctx); err != nil {
+		log.Fatalf("❌ Failed to start live post updates: %v", err)
+	}
+	likeFraudUC := usecase.NewLikeFraudUsecase(userRepo, likeRepo, likeFlagRepo, usecase.LikeFraudOptions{
+		NewAccountAge:      cfg.Likes.NewAccountAge,
+		NewAccountDiscount: cfg.Likes.NewAccountDiscount,
+		BurstWindow:        cfg.Likes.BurstWindow,
+		BurstMinLikes:      cfg.Likes.BurstMinLikes,
+		BurstNewRatio:      cfg.Likes.BurstNewRatio,
+		BurstDiscount:      cfg.Likes.BurstDiscount,
+		CoLikeWindow:       cfg.Likes.CoLikeWindow,
+		CoLikeMinShared:    cfg.Likes.CoLikeMinShared,
+		CoLikeMinUsers:     cfg.Likes.CoLikeMinUsers,
+		CoLikeDiscount:     cfg.Likes.CoLikeDiscount,
+	})
+	likeUC := usecase.NewLikeUsecase(likeRepo, postRepo, likeFraudUC, tx, outboxUC)
+	commentUC := usecase.NewCommentUsecase(commentRepo, tx, outboxUC)
+
+	var dedup event.DedupStore
+	var rateLimiter repository.RateLimiter
+	if redisClient != nil {
+		dedup = event.NewDedupStoreRedis(redisClient)
+		rateLimiter = repository.NewRateLimiterRedis(redisClient)
+	} else {
+		dedup = event.NewDedupStoreMemory()
+		rateLimiter = repository.NewRateLimiterMemory()
+	}
+	registerSubscribers(bus, dedup, cfg.Events.DedupTTL, trendingUC, postLiveUC, viewUC)
+	if err := bus.Start(ctx); err != nil {
+		log.Fatalf("❌ Failed to start event bus: %v", err)
+	}
+	go outboxUC.RunRelay(ctx, cfg.Events.OutboxInterval, cfg.Events.OutboxBatch)
 
 	userController := controller.NewUserController(userUC)
-	postController := controller.NewPostController(postUC)
+	postController := controller.NewPostController(postUC, viewUC, postSummaryUC)
 	likeController := controller.NewLikeController(likeUC)
 	commentController := controller.NewCommentController(commentUC)
+	trendingController := controller.NewTrendingController(trendingUC, trendingStreamUC, snapshotUC)
+	liveController := controller.NewLiveController(postLiveUC, postUC)
+	tagController := controller.NewTagController(postUC, trendingUC, postSummaryUC)
 
 	authMiddleware := middleware.AuthMiddleware(jwtService)
+	liveAuthMiddleware := middleware.WebSocketAuthMiddleware(jwtService)
+	optionalAuthMiddleware := middleware.OptionalAuthMiddleware(jwtService)
+	likeRateLimitMiddleware := middleware.RateLimitMiddleware(rateLimiter, "likes", cfg.Likes.RatePerUser, cfg.Likes.RatePerIP, cfg.Likes.RateWindow)
+	viewRateLimitMiddleware := middleware.RateLimitMiddleware(rateLimiter, "views", 0, cfg.Views.RatePerIP, cfg.Views.RateWindow)
+	explainRateLimitMiddleware := middleware.RateLimitMiddleware(rateLimiter, "explain", cfg.Trending.ExplainRatePerUser, cfg.Trending.ExplainRatePerIP, cfg.Trending.ExplainRateWindow)
+
+	if cfg.App.DebugAddr != "" {
+		debug := gin.New()
+		routes.DebugRoutes(debug)
+		go func() {
+			if err := debug.Run(cfg.App.DebugAddr); err != nil {
+				log.Printf("⚠️ Debug server stopped: %v", err)
+			}
+		}()
+	}
 
 	r := gin.Default()
-	routes.SetupRoutes(r, userController, postController, likeController, commentController, authMiddleware)
+	// Rate limits and anonymous views go by ClientIP, which would otherwise
+	// take X-Forwarded-For from any client.
+	if err := r.SetTrustedProxies(cfg.App.TrustedProxies); err != nil {
+		log.Fatalf("❌ Invalid APP_TRUSTED_PROXIES: %v", err)
+	}
+	routes.SetupRoutes(r, userController, postController, likeController, commentController, trendingController, liveController, tagController, authMiddleware, liveAuthMiddleware, optionalAuthMiddleware, likeRateLimitMiddleware, viewRateLimitMiddleware, explainRateLimitMiddleware)
 
 	r.Run(":" + cfg.App.Port)
 }
+
+// reloadWeightsOnHangup re-reads the engagement weights whenever the process
+// receives SIGHUP, so they can be tuned without a redeploy.
+func reloadWeightsOnHangup(ctx context.Context, trendingUC usecase.TrendingUsecase) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			weights := engagementWeights(config.ReloadEngagementWeights())
+			if err := trendingUC.SetEngagementWeights(ctx, weights); err != nil {
+				log.Printf("⚠️ Failed to apply engagement weights: %v", err)
+				continue
+			}
+			log.Printf("🔧 Reloaded engagement weights: %+v", weights)
+		}
+	}
+}
+
+func engagementWeights(w config.EngagementWeights) usecase.EngagementWeights {
+	return usecase.EngagementWeights{
+		Likes:    w.Likes,
+		Comments: w.Comments,
+		Views:    w.Views,
+		Age:      w.Age,
+	}
+}
+
+// registerSubscribers wires the side effects that follow domain events.
+// Events can be delivered more than once, so subscribers that are not
+// idempotent are wrapped in a dedup check.
+func registerSubscribers(bus event.Bus, dedup event.DedupStore, dedupTTL time.Duration, trendingUC usecase.TrendingUsecase, postLiveUC usecase.PostLiveUsecase, viewUC usecase.ViewUsecase) {
+	bus.Subscribe("trending", event.Dedup("trending", dedup, dedupTTL, func(ctx context.Context, e event.Event) error {
+		switch e := e.(type) {
+		case event.LikeAdded:
+			if err := trendingUC.RecordLike(ctx, e.PostID, e.LikedAt, 1-e.Discount); err != nil {
+				return err
+			}
+			return trendingUC.RecordKarma(ctx, e.AuthorID, e.LikedAt, 1-e.Discount)
+		case event.LikeRemoved:
+			if err := trendingUC.RecordUnlike(ctx, e.PostID, e.LikedAt, 1-e.Discount); err != nil {
+				return err
+			}
+			return trendingUC.RecordKarma(ctx, e.AuthorID, e.LikedAt, e.Discount-1)
+		case event.CommentCreated:
+			return trendingUC.RecordComment(ctx, e.PostID, e.At)
+		case event.CommentDeleted:
+			return trendingUC.RecordUncomment(ctx, e.PostID, e.CreatedAt)
+		case event.PostViewed:
+			return trendingUC.RecordView(ctx, e.PostID, e.At)
+		case event.PostCreated:
+			return trendingUC.RecordPost(ctx, e.PostID, e.At)
+		case event.PostRetagged:
+			return trendingUC.RetagPost(ctx, e.PostID, e.Added, e.Removed)
+		case event.PostDeleted:
+			return trendingUC.RemovePost(ctx, e.PostID, e.AuthorID, e.Tags)
+		}
+		return nil
+	}), event.TypeLikeAdded, event.TypeLikeRemoved, event.TypeCommentCreated, event.TypeCommentDeleted,
+		event.TypePostViewed, event.TypePostCreated, event.TypePostRetagged, event.TypePostDeleted)
+
+	bus.Subscribe("views", func(ctx context.Context, e event.Event) error {
+		return viewUC.RemovePost(ctx, e.(event.PostDeleted).PostID)
+	}, event.TypePostDeleted)
+
+	bus.Subscribe("live", func(ctx context.Context, e event.Event) error {
+		switch e := e.(type) {
+		case event.LikeAdded:
+			return postLiveUC.Publish(ctx, e.PostID, usecase.PostLiveLiked)
+		case event.LikeRemoved:
+			return postLiveUC.Publish(ctx, e.PostID, usecase.PostLiveUnliked)
+		case event.CommentCreated:
+			return postLiveUC.Publish(ctx, e.PostID, usecase.PostLiveCommentCreated)
+		case event.CommentDeleted:
+			return postLiveUC.Publish(ctx, e.PostID, usecase.PostLiveCommentDeleted)
+		}
+		return nil
+	}, event.TypeLikeAdded, event.TypeLikeRemoved, event.TypeCommentCreated, event.TypeCommentDeleted)
+}
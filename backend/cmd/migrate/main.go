@@ -1,26 +1,67 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
 
 	"backend/config"
 	"backend/internal/entity"
+	"backend/internal/repository"
+	"gorm.io/gorm"
 )
 
+// Run without arguments to migrate the schema, or with "recount" to repair
+// the denormalized counters.
 func main() {
 	cfg := config.LoadConfig()
 
 	db := config.InitDB(cfg)
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "recount":
+			recount(db)
+		default:
+			log.Fatalf("❌ Unknown command %q (expected recount)", os.Args[1])
+		}
+		return
+	}
+	migrate(db)
+}
+
+func migrate(db *gorm.DB) {
 	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
 		log.Fatalf("❌ Failed to enable uuid-ossp extension: %v", err)
 	}
 
+	// The (user_id, post_id) unique index cannot be built while duplicate
+	// likes from the old read-then-write toggle remain; keep the earliest.
+	if db.Migrator().HasTable(&entity.Like{}) {
+		if err := db.Exec(`
+			DELETE FROM likes a
+			USING likes b
+			WHERE a.user_id = b.user_id
+			  AND a.post_id = b.post_id
+			  AND (a.created_at, a.id) > (b.created_at, b.id)
+		`).Error; err != nil {
+			log.Fatalf("❌ Failed to remove duplicate likes: %v", err)
+		}
+	}
+
+	// Counters added to existing posts start at zero and are filled in
+	// once the columns exist.
+	backfill := db.Migrator().HasTable(&entity.Post{}) && !db.Migrator().HasColumn(&entity.Post{}, "like_count")
+
 	if err := db.AutoMigrate(
 		&entity.User{},
 		&entity.Post{},
+		&entity.Tag{},
 		&entity.Like{},
 		&entity.Comment{},
+		&entity.OutboxEntry{},
+		&entity.TrendingSnapshot{},
+		&entity.LikeFlag{},
 	); err != nil {
 		log.Fatalf("❌ Migration failed: %v", err)
 	}
@@ -41,5 +82,27 @@ func main() {
 		}
 	}
 
+	if backfill {
+		recount(db)
+	}
+
 	log.Println("✅ Migrations completed successfully")
 }
+
+// recount sets the like and comment counters of posts and the karma of
+// users from the rows they count. Likes still buffered in Redis are not in
+// Postgres yet; the post counters catch up when they are flushed, karma
+// does not, so run it with the buffer drained.
+func recount(db *gorm.DB) {
+	ctx := context.Background()
+
+	likes, comments, err := repository.NewPostRepositoryGorm(db).RecountCounters(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to recount post counters: %v", err)
+	}
+	karma, err := repository.NewUserRepository(db).RecountKarma(ctx)
+	if err != nil {
+		log.Fatalf("❌ Failed to recount karma: %v", err)
+	}
+	log.Printf("✅ Recounted counters: %d like counts, %d comment counts and %d karma totals repaired", likes, comments, karma)
+}
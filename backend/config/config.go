@@ -3,6 +3,7 @@ package config
 import (
 	"backend/pkg/utils"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +15,12 @@ type Config struct {
 		Name string
 		Env  string
 		Port string
+		// DebugAddr is where runtime metrics are served, apart from the
+		// public API. Empty turns them off.
+		DebugAddr string
+		// TrustedProxies are the proxies whose X-Forwarded-For is believed
+		// when working out a client's IP. By default none are.
+		TrustedProxies []string
 	}
 
 	DB struct {
@@ -46,11 +53,86 @@ type Config struct {
 		RefreshExpire time.Duration
 	}
 
+	Trending struct {
+		Backend        string
+		HalfLife       time.Duration
+		Gravity        float64
+		WindowCacheTTL time.Duration
+		SketchAlgo     string
+		SketchEpsilon  float64
+		SketchDelta    float64
+		RebuildOnStart bool
+		DriftInterval  time.Duration
+		DriftSample    int
+		StreamInterval time.Duration
+		StreamK        int
+		StreamHistory  int
+		Engagement     EngagementWeights
+		Rising         struct {
+			Recent      time.Duration
+			Baseline    time.Duration
+			MinActivity float64
+		}
+		SnapshotInterval  time.Duration
+		SnapshotK         int
+		SnapshotRetention time.Duration
+
+		ExplainRatePerUser int
+		ExplainRatePerIP   int
+		ExplainRateWindow  time.Duration
+	}
+
+	Likes struct {
+		WriteMode     string
+		FlushInterval time.Duration
+		FlushBatch    int
+
+		RatePerUser int
+		RatePerIP   int
+		RateWindow  time.Duration
+
+		NewAccountAge      time.Duration
+		NewAccountDiscount float64
+		BurstWindow        time.Duration
+		BurstMinLikes      int
+		BurstNewRatio      float64
+		BurstDiscount      float64
+		CoLikeWindow       time.Duration
+		CoLikeMinShared    int
+		CoLikeMinUsers     int
+		CoLikeDiscount     float64
+	}
+
+	Views struct {
+		RatePerIP  int
+		RateWindow time.Duration
+	}
+
+	Events struct {
+		Backend           string
+		OutboxInterval    time.Duration
+		OutboxBatch       int
+		OutboxMaxAttempts int
+		OutboxBackoff     time.Duration
+		OutboxMaxBackoff  time.Duration
+		OutboxRetention   time.Duration
+		DedupTTL          time.Duration
+	}
+
 	Log struct {
 		Level string
 	}
 }
 
+// EngagementWeights are the weights of the engagement trending score. Unlike
+// the rest of the config they can be reloaded while the service runs.
+type EngagementWeights struct {
+	Likes    float64
+	Comments float64
+	Views    float64
+	Age      float64
+}
+
 func LoadConfig() *Config {
 	// Load .env if present
 	_ = godotenv.Load()
@@ -63,6 +145,13 @@ func LoadConfig() *Config {
 	cfg.App.Name = viper.GetString("APP_NAME")
 	cfg.App.Env = viper.GetString("APP_ENV")
 	cfg.App.Port = viper.GetString("APP_PORT")
+	viper.SetDefault("APP_DEBUG_ADDR", "127.0.0.1:6060")
+	cfg.App.DebugAddr = viper.GetString("APP_DEBUG_ADDR")
+	for _, proxy := range strings.Split(viper.GetString("APP_TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.App.TrustedProxies = append(cfg.App.TrustedProxies, proxy)
+		}
+	}
 
 	// Database
 	cfg.DB.Host = viper.GetString("DB_HOST")
@@ -100,8 +189,266 @@ func LoadConfig() *Config {
 	}
 	cfg.JWT.RefreshExpire = refreshExpire
 
+	// Trending
+	viper.SetDefault("TRENDING_BACKEND", "redis")
+	viper.SetDefault("TRENDING_HALF_LIFE", "24h")
+	viper.SetDefault("TRENDING_GRAVITY", 1.8)
+	viper.SetDefault("TRENDING_WINDOW_CACHE_TTL", "10s")
+	viper.SetDefault("TRENDING_SKETCH_ALGO", "spacesaving")
+	viper.SetDefault("TRENDING_SKETCH_EPSILON", 0.001)
+	viper.SetDefault("TRENDING_SKETCH_DELTA", 0.01)
+	viper.SetDefault("TRENDING_REBUILD_ON_START", true)
+	viper.SetDefault("TRENDING_DRIFT_INTERVAL", "5m")
+	viper.SetDefault("TRENDING_DRIFT_SAMPLE", 100)
+	viper.SetDefault("TRENDING_STREAM_INTERVAL", "1s")
+	viper.SetDefault("TRENDING_STREAM_K", 10)
+	viper.SetDefault("TRENDING_STREAM_HISTORY", 100)
+	viper.SetDefault("TRENDING_WEIGHT_LIKES", 1.0)
+	viper.SetDefault("TRENDING_WEIGHT_COMMENTS", 3.0)
+	viper.SetDefault("TRENDING_WEIGHT_VIEWS", 0.1)
+	viper.SetDefault("TRENDING_WEIGHT_AGE", 0.05)
+	viper.SetDefault("TRENDING_RISING_RECENT", "10m")
+	viper.SetDefault("TRENDING_RISING_BASELINE", "1h")
+	viper.SetDefault("TRENDING_RISING_MIN_ACTIVITY", 5)
+	viper.SetDefault("TRENDING_SNAPSHOT_INTERVAL", "1h")
+	viper.SetDefault("TRENDING_SNAPSHOT_K", 50)
+	viper.SetDefault("TRENDING_SNAPSHOT_RETENTION", "90d")
+	viper.SetDefault("TRENDING_EXPLAIN_RATE_PER_USER", 10)
+	viper.SetDefault("TRENDING_EXPLAIN_RATE_PER_IP", 30)
+	viper.SetDefault("TRENDING_EXPLAIN_RATE_WINDOW", "1m")
+
+	halfLife, err := utils.ParseDuration(viper.GetString("TRENDING_HALF_LIFE"))
+	if err != nil || halfLife <= 0 {
+		log.Fatal("invalid TRENDING_HALF_LIFE format")
+	}
+	cfg.Trending.Backend = viper.GetString("TRENDING_BACKEND")
+	cfg.Trending.HalfLife = halfLife
+	cfg.Trending.Gravity = viper.GetFloat64("TRENDING_GRAVITY")
+
+	windowCacheTTL, err := utils.ParseDuration(viper.GetString("TRENDING_WINDOW_CACHE_TTL"))
+	if err != nil || windowCacheTTL <= 0 {
+		log.Fatal("invalid TRENDING_WINDOW_CACHE_TTL format")
+	}
+	cfg.Trending.WindowCacheTTL = windowCacheTTL
+	cfg.Trending.SketchAlgo = viper.GetString("TRENDING_SKETCH_ALGO")
+	cfg.Trending.SketchEpsilon = viper.GetFloat64("TRENDING_SKETCH_EPSILON")
+	cfg.Trending.SketchDelta = viper.GetFloat64("TRENDING_SKETCH_DELTA")
+	cfg.Trending.RebuildOnStart = viper.GetBool("TRENDING_REBUILD_ON_START")
+	cfg.Trending.DriftSample = viper.GetInt("TRENDING_DRIFT_SAMPLE")
+	if cfg.Trending.DriftSample < 2 {
+		log.Fatal("TRENDING_DRIFT_SAMPLE must be at least 2")
+	}
+
+	driftInterval, err := utils.ParseDuration(viper.GetString("TRENDING_DRIFT_INTERVAL"))
+	if err != nil {
+		log.Fatal("invalid TRENDING_DRIFT_INTERVAL format")
+	}
+	cfg.Trending.DriftInterval = driftInterval
+
+	streamInterval, err := utils.ParseDuration(viper.GetString("TRENDING_STREAM_INTERVAL"))
+	if err != nil || streamInterval <= 0 {
+		log.Fatal("invalid TRENDING_STREAM_INTERVAL format")
+	}
+	cfg.Trending.StreamInterval = streamInterval
+	cfg.Trending.StreamK = viper.GetInt("TRENDING_STREAM_K")
+	if cfg.Trending.StreamK <= 0 {
+		log.Fatal("TRENDING_STREAM_K must be greater than 0")
+	}
+	cfg.Trending.StreamHistory = viper.GetInt("TRENDING_STREAM_HISTORY")
+	if cfg.Trending.StreamHistory <= 0 {
+		log.Fatal("TRENDING_STREAM_HISTORY must be greater than 0")
+	}
+	cfg.Trending.Engagement = loadEngagementWeights()
+
+	risingRecent, err := utils.ParseDuration(viper.GetString("TRENDING_RISING_RECENT"))
+	if err != nil || risingRecent < time.Minute || risingRecent%time.Minute != 0 {
+		log.Fatal("invalid TRENDING_RISING_RECENT format (whole minutes)")
+	}
+	cfg.Trending.Rising.Recent = risingRecent
+
+	risingBaseline, err := utils.ParseDuration(viper.GetString("TRENDING_RISING_BASELINE"))
+	if err != nil || risingBaseline < time.Minute || risingBaseline%time.Minute != 0 {
+		log.Fatal("invalid TRENDING_RISING_BASELINE format (whole minutes)")
+	}
+	cfg.Trending.Rising.Baseline = risingBaseline
+	cfg.Trending.Rising.MinActivity = viper.GetFloat64("TRENDING_RISING_MIN_ACTIVITY")
+
+	snapshotInterval, err := utils.ParseDuration(viper.GetString("TRENDING_SNAPSHOT_INTERVAL"))
+	if err != nil {
+		log.Fatal("invalid TRENDING_SNAPSHOT_INTERVAL format")
+	}
+	cfg.Trending.SnapshotInterval = snapshotInterval
+	cfg.Trending.SnapshotK = viper.GetInt("TRENDING_SNAPSHOT_K")
+	if cfg.Trending.SnapshotK <= 0 {
+		log.Fatal("TRENDING_SNAPSHOT_K must be greater than 0")
+	}
+
+	snapshotRetention, err := utils.ParseDuration(viper.GetString("TRENDING_SNAPSHOT_RETENTION"))
+	if err != nil || snapshotRetention <= 0 {
+		log.Fatal("invalid TRENDING_SNAPSHOT_RETENTION format")
+	}
+	cfg.Trending.SnapshotRetention = snapshotRetention
+
+	cfg.Trending.ExplainRatePerUser = viper.GetInt("TRENDING_EXPLAIN_RATE_PER_USER")
+	cfg.Trending.ExplainRatePerIP = viper.GetInt("TRENDING_EXPLAIN_RATE_PER_IP")
+	explainRateWindow, err := utils.ParseDuration(viper.GetString("TRENDING_EXPLAIN_RATE_WINDOW"))
+	if err != nil || explainRateWindow <= 0 {
+		log.Fatal("invalid TRENDING_EXPLAIN_RATE_WINDOW format")
+	}
+	cfg.Trending.ExplainRateWindow = explainRateWindow
+
+	// Likes
+	viper.SetDefault("LIKES_WRITE_MODE", "direct")
+	viper.SetDefault("LIKES_FLUSH_INTERVAL", "1s")
+	viper.SetDefault("LIKES_FLUSH_BATCH", 500)
+	viper.SetDefault("LIKES_RATE_PER_USER", 30)
+	viper.SetDefault("LIKES_RATE_PER_IP", 120)
+	viper.SetDefault("LIKES_RATE_WINDOW", "1m")
+	viper.SetDefault("LIKES_NEW_ACCOUNT_AGE", "24h")
+	viper.SetDefault("LIKES_NEW_ACCOUNT_DISCOUNT", 0.5)
+	viper.SetDefault("LIKES_BURST_WINDOW", "10m")
+	viper.SetDefault("LIKES_BURST_MIN_LIKES", 20)
+	viper.SetDefault("LIKES_BURST_NEW_RATIO", 0.5)
+	viper.SetDefault("LIKES_BURST_DISCOUNT", 1.0)
+	viper.SetDefault("LIKES_COLIKE_WINDOW", "24h")
+	viper.SetDefault("LIKES_COLIKE_MIN_SHARED", 5)
+	viper.SetDefault("LIKES_COLIKE_MIN_USERS", 3)
+	viper.SetDefault("LIKES_COLIKE_DISCOUNT", 0.8)
+
+	cfg.Likes.WriteMode = viper.GetString("LIKES_WRITE_MODE")
+	cfg.Likes.FlushBatch = viper.GetInt("LIKES_FLUSH_BATCH")
+	if cfg.Likes.FlushBatch <= 0 {
+		log.Fatal("LIKES_FLUSH_BATCH must be greater than 0")
+	}
+
+	flushInterval, err := utils.ParseDuration(viper.GetString("LIKES_FLUSH_INTERVAL"))
+	if err != nil || flushInterval <= 0 {
+		log.Fatal("invalid LIKES_FLUSH_INTERVAL format")
+	}
+	cfg.Likes.FlushInterval = flushInterval
+
+	cfg.Likes.RatePerUser = viper.GetInt("LIKES_RATE_PER_USER")
+	cfg.Likes.RatePerIP = viper.GetInt("LIKES_RATE_PER_IP")
+	rateWindow, err := utils.ParseDuration(viper.GetString("LIKES_RATE_WINDOW"))
+	if err != nil || rateWindow <= 0 {
+		log.Fatal("invalid LIKES_RATE_WINDOW format")
+	}
+	cfg.Likes.RateWindow = rateWindow
+
+	newAccountAge, err := utils.ParseDuration(viper.GetString("LIKES_NEW_ACCOUNT_AGE"))
+	if err != nil {
+		log.Fatal("invalid LIKES_NEW_ACCOUNT_AGE format")
+	}
+	cfg.Likes.NewAccountAge = newAccountAge
+	cfg.Likes.NewAccountDiscount = viper.GetFloat64("LIKES_NEW_ACCOUNT_DISCOUNT")
+
+	burstWindow, err := utils.ParseDuration(viper.GetString("LIKES_BURST_WINDOW"))
+	if err != nil {
+		log.Fatal("invalid LIKES_BURST_WINDOW format")
+	}
+	cfg.Likes.BurstWindow = burstWindow
+	cfg.Likes.BurstMinLikes = viper.GetInt("LIKES_BURST_MIN_LIKES")
+	cfg.Likes.BurstNewRatio = viper.GetFloat64("LIKES_BURST_NEW_RATIO")
+	cfg.Likes.BurstDiscount = viper.GetFloat64("LIKES_BURST_DISCOUNT")
+
+	coLikeWindow, err := utils.ParseDuration(viper.GetString("LIKES_COLIKE_WINDOW"))
+	if err != nil {
+		log.Fatal("invalid LIKES_COLIKE_WINDOW format")
+	}
+	cfg.Likes.CoLikeWindow = coLikeWindow
+	cfg.Likes.CoLikeMinShared = viper.GetInt("LIKES_COLIKE_MIN_SHARED")
+	cfg.Likes.CoLikeMinUsers = viper.GetInt("LIKES_COLIKE_MIN_USERS")
+	cfg.Likes.CoLikeDiscount = viper.GetFloat64("LIKES_COLIKE_DISCOUNT")
+
+	for name, discount := range map[string]float64{
+		"LIKES_NEW_ACCOUNT_DISCOUNT": cfg.Likes.NewAccountDiscount,
+		"LIKES_BURST_DISCOUNT":       cfg.Likes.BurstDiscount,
+		"LIKES_COLIKE_DISCOUNT":      cfg.Likes.CoLikeDiscount,
+	} {
+		if discount < 0 || discount > 1 {
+			log.Fatalf("%s must be between 0 and 1", name)
+		}
+	}
+
+	// Views
+	viper.SetDefault("VIEWS_RATE_PER_IP", 60)
+	viper.SetDefault("VIEWS_RATE_WINDOW", "1m")
+
+	cfg.Views.RatePerIP = viper.GetInt("VIEWS_RATE_PER_IP")
+	viewRateWindow, err := utils.ParseDuration(viper.GetString("VIEWS_RATE_WINDOW"))
+	if err != nil || viewRateWindow <= 0 {
+		log.Fatal("invalid VIEWS_RATE_WINDOW format")
+	}
+	cfg.Views.RateWindow = viewRateWindow
+
+	// Events
+	viper.SetDefault("EVENTS_BACKEND", "sync")
+	viper.SetDefault("EVENTS_OUTBOX_INTERVAL", "500ms")
+	viper.SetDefault("EVENTS_OUTBOX_BATCH", 100)
+	viper.SetDefault("EVENTS_OUTBOX_MAX_ATTEMPTS", 10)
+	viper.SetDefault("EVENTS_OUTBOX_BACKOFF", "1s")
+	viper.SetDefault("EVENTS_OUTBOX_MAX_BACKOFF", "5m")
+	viper.SetDefault("EVENTS_OUTBOX_RETENTION", "24h")
+	viper.SetDefault("EVENTS_DEDUP_TTL", "24h")
+
+	cfg.Events.Backend = viper.GetString("EVENTS_BACKEND")
+	cfg.Events.OutboxBatch = viper.GetInt("EVENTS_OUTBOX_BATCH")
+	if cfg.Events.OutboxBatch <= 0 {
+		log.Fatal("EVENTS_OUTBOX_BATCH must be greater than 0")
+	}
+	cfg.Events.OutboxMaxAttempts = viper.GetInt("EVENTS_OUTBOX_MAX_ATTEMPTS")
+	if cfg.Events.OutboxMaxAttempts <= 0 {
+		log.Fatal("EVENTS_OUTBOX_MAX_ATTEMPTS must be greater than 0")
+	}
+
+	outboxInterval, err := utils.ParseDuration(viper.GetString("EVENTS_OUTBOX_INTERVAL"))
+	if err != nil || outboxInterval <= 0 {
+		log.Fatal("invalid EVENTS_OUTBOX_INTERVAL format")
+	}
+	cfg.Events.OutboxInterval = outboxInterval
+
+	outboxBackoff, err := utils.ParseDuration(viper.GetString("EVENTS_OUTBOX_BACKOFF"))
+	if err != nil || outboxBackoff <= 0 {
+		log.Fatal("invalid EVENTS_OUTBOX_BACKOFF format")
+	}
+	cfg.Events.OutboxBackoff = outboxBackoff
+
+	outboxMaxBackoff, err := utils.ParseDuration(viper.GetString("EVENTS_OUTBOX_MAX_BACKOFF"))
+	if err != nil || outboxMaxBackoff <= 0 {
+		log.Fatal("invalid EVENTS_OUTBOX_MAX_BACKOFF format")
+	}
+	cfg.Events.OutboxMaxBackoff = outboxMaxBackoff
+
+	outboxRetention, err := utils.ParseDuration(viper.GetString("EVENTS_OUTBOX_RETENTION"))
+	if err != nil || outboxRetention <= 0 {
+		log.Fatal("invalid EVENTS_OUTBOX_RETENTION format")
+	}
+	cfg.Events.OutboxRetention = outboxRetention
+
+	dedupTTL, err := utils.ParseDuration(viper.GetString("EVENTS_DEDUP_TTL"))
+	if err != nil || dedupTTL <= 0 {
+		log.Fatal("invalid EVENTS_DEDUP_TTL format")
+	}
+	cfg.Events.DedupTTL = dedupTTL
+
 	// Logging
 	cfg.Log.Level = viper.GetString("LOG_LEVEL")
 
 	return cfg
 }
+
+func loadEngagementWeights() EngagementWeights {
+	return EngagementWeights{
+		Likes:    viper.GetFloat64("TRENDING_WEIGHT_LIKES"),
+		Comments: viper.GetFloat64("TRENDING_WEIGHT_COMMENTS"),
+		Views:    viper.GetFloat64("TRENDING_WEIGHT_VIEWS"),
+		Age:      viper.GetFloat64("TRENDING_WEIGHT_AGE"),
+	}
+}
+
+// ReloadEngagementWeights re-reads the engagement weights, letting values in
+// .env override the ones loaded at startup.
+func ReloadEngagementWeights() EngagementWeights {
+	_ = godotenv.Overload()
+	return loadEngagementWeights()
+}
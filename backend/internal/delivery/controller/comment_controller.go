@@ -167,12 +167,17 @@ func (c *CommentController) GetCommentsByPostID(ctx *gin.Context) {
 		return
 	}
 
-	comments, err := c.uc.GetCommentsByPostID(ctx, postID)
+	page, ok := parsePage(ctx)
+	if !ok {
+		return
+	}
+
+	comments, info, err := c.uc.GetCommentsByPostID(ctx, postID, page)
 	if err != nil {
 		response.Error(ctx, http.StatusInternalServerError, "Failed to fetch comments", []response.APIError{
 			{Code: "DB_ERROR", Detail: err.Error()},
 		})
 		return
 	}
-	response.Success(ctx, http.StatusOK, "Comments retrieved", comments)
+	response.Success(ctx, http.StatusOK, "Comments retrieved", comments, pageMeta(info))
 }
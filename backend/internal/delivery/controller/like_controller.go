@@ -37,13 +37,70 @@ func (lc *LikeController) ToggleLike(c *gin.Context) {
 	userClaims := claims.(*jwt.Claims)
 	userID, _ := uuid.Parse(userClaims.UserID)
 
-	if err := lc.likeUC.ToggleLike(c.Request.Context(), postID, userID); err != nil {
+	liked, err := lc.likeUC.ToggleLike(c.Request.Context(), postID, userID)
+	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to toggle like", []response.APIError{
 			{Code: "DB_ERROR", Detail: err.Error()},
 		})
 		return
 	}
-	response.Success(c, http.StatusOK, "Like toggled", nil)
+	response.Success(c, http.StatusOK, "Like toggled", gin.H{"liked": liked})
+}
+
+func (lc *LikeController) LikePost(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("post_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", []response.APIError{
+			{Field: "post_id", Code: "INVALID_UUID", Detail: err.Error()},
+		})
+		return
+	}
+
+	claims, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", []response.APIError{
+			{Code: "UNAUTHORIZED", Detail: "Missing or invalid authentication token"},
+		})
+		return
+	}
+	userClaims := claims.(*jwt.Claims)
+	userID, _ := uuid.Parse(userClaims.UserID)
+
+	if err := lc.likeUC.LikePost(c.Request.Context(), postID, userID); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to like post", []response.APIError{
+			{Code: "DB_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Post liked", gin.H{"liked": true})
+}
+
+func (lc *LikeController) UnlikePost(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("post_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", []response.APIError{
+			{Field: "post_id", Code: "INVALID_UUID", Detail: err.Error()},
+		})
+		return
+	}
+
+	claims, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", []response.APIError{
+			{Code: "UNAUTHORIZED", Detail: "Missing or invalid authentication token"},
+		})
+		return
+	}
+	userClaims := claims.(*jwt.Claims)
+	userID, _ := uuid.Parse(userClaims.UserID)
+
+	if err := lc.likeUC.UnlikePost(c.Request.Context(), postID, userID); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to unlike post", []response.APIError{
+			{Code: "DB_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Post unliked", gin.H{"liked": false})
 }
 
 func (lc *LikeController) GetLikesByPost(c *gin.Context) {
@@ -55,12 +112,17 @@ func (lc *LikeController) GetLikesByPost(c *gin.Context) {
 		return
 	}
 
-	likes, err := lc.likeUC.GetLikesByPost(c.Request.Context(), postID)
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	likes, info, err := lc.likeUC.GetLikesByPost(c.Request.Context(), postID, page)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to fetch likes", []response.APIError{
 			{Code: "DB_ERROR", Detail: err.Error()},
 		})
 		return
 	}
-	response.Success(c, http.StatusOK, "Likes retrieved", likes)
+	response.Success(c, http.StatusOK, "Likes retrieved", likes, pageMeta(info))
 }
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"backend/internal/usecase"
+	"backend/pkg/response"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	liveWriteWait  = 10 * time.Second
+	livePongWait   = 60 * time.Second
+	livePingPeriod = livePongWait * 9 / 10
+	// Viewers only receive; anything they send is read and discarded.
+	liveMaxMessageSize = 512
+)
+
+var liveUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The handshake is authenticated with a bearer token rather than
+	// cookies, so cross-origin clients are allowed.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type LiveController struct {
+	liveUC usecase.PostLiveUsecase
+	postUC usecase.PostUsecase
+}
+
+func NewLiveController(liveUC usecase.PostLiveUsecase, postUC usecase.PostUsecase) *LiveController {
+	return &LiveController{liveUC: liveUC, postUC: postUC}
+}
+
+// PostLive upgrades to a WebSocket that receives the post's like and comment
+// counts whenever they change.
+func (lc *LiveController) PostLive(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", []response.APIError{
+			{Field: "id", Code: "INVALID_UUID", Detail: err.Error()},
+		})
+		return
+	}
+	if _, err := lc.postUC.GetPostByID(c.Request.Context(), postID); err != nil {
+		response.Error(c, http.StatusNotFound, "Post not found", []response.APIError{
+			{Field: "id", Code: "NOT_FOUND", Detail: err.Error()},
+		})
+		return
+	}
+
+	// The request context is not cancelled when a hijacked connection
+	// closes, so the read loop cancels this one instead.
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	updates, err := lc.liveUC.Subscribe(ctx, postID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to subscribe to post", []response.APIError{
+			{Code: "LIVE_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+
+	conn, err := liveUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered with an HTTP error.
+		return
+	}
+	defer conn.Close()
+
+	go readLive(conn, cancel)
+	writeLive(conn, updates)
+}
+
+// readLive keeps the read deadline moving while pongs arrive and cancels the
+// connection once the client closes it or stops answering pings.
+func readLive(conn *websocket.Conn, cancel context.CancelFunc) {
+	defer cancel()
+
+	conn.SetReadLimit(liveMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(livePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writeLive sends updates and pings until the subscription ends or a write
+// fails. A client too slow to take a write within liveWriteWait is
+// disconnected; short of that, updates it has not taken yet are replaced by
+// newer ones.
+func writeLive(conn *websocket.Conn, updates <-chan []byte) {
+	ping := time.NewTicker(livePingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case data, ok := <-updates:
+			if !ok {
+				// The subscription ends with ctx; say goodbye in case the
+				// client is still there.
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(liveWriteWait))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
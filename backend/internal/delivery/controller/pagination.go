@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"backend/pkg/pagination"
+	"backend/pkg/response"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePage reads the limit and cursor query parameters, responding with 400
+// if either is invalid.
+func parsePage(c *gin.Context) (pagination.Params, bool) {
+	page := pagination.Params{Limit: pagination.DefaultLimit}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > pagination.MaxLimit {
+			response.Error(c, http.StatusBadRequest, "Invalid limit", []response.APIError{
+				{Field: "limit", Code: "INVALID_QUERY", Detail: fmt.Sprintf("limit must be an integer between 1 and %d", pagination.MaxLimit)},
+			})
+			return page, false
+		}
+		page.Limit = limit
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := pagination.Decode(raw)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid cursor", []response.APIError{
+				{Field: "cursor", Code: "INVALID_CURSOR", Detail: err.Error()},
+			})
+			return page, false
+		}
+		page.Cursor = cursor
+	}
+	return page, true
+}
+
+func pageMeta(info pagination.Info) response.Meta {
+	m := response.NewMeta()
+	m.Pagination = info
+	return m
+}
@@ -3,20 +3,34 @@ package controller
 import (
 	"backend/internal/entity"
 	"backend/internal/usecase"
+	"backend/pkg/hashtag"
 	"backend/pkg/jwt"
+	"backend/pkg/pagination"
 	"backend/pkg/response"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type PostController struct {
-	postUsecase usecase.PostUsecase
+	postUsecase    usecase.PostUsecase
+	viewUsecase    usecase.ViewUsecase
+	summaryUsecase usecase.PostSummaryUsecase
 }
 
-func NewPostController(postUsecase usecase.PostUsecase) *PostController {
-	return &PostController{postUsecase: postUsecase}
+func NewPostController(postUsecase usecase.PostUsecase, viewUsecase usecase.ViewUsecase, summaryUsecase usecase.PostSummaryUsecase) *PostController {
+	return &PostController{
+		postUsecase:    postUsecase,
+		viewUsecase:    viewUsecase,
+		summaryUsecase: summaryUsecase,
+	}
 }
 
 func (pc *PostController) CreatePost(c *gin.Context) {
@@ -73,18 +87,187 @@ func (pc *PostController) GetPostByID(c *gin.Context) {
 		})
 		return
 	}
-	response.Success(c, http.StatusOK, "Post retrieved", post)
+
+	// A view that fails to count must not fail the read.
+	if err := pc.viewUsecase.RecordView(c.Request.Context(), id, viewerID(c)); err != nil {
+		log.Printf("⚠️ Failed to record view of post %s: %v", id, err)
+	}
+	summaries, err := pc.summaryUsecase.Summarize(c.Request.Context(), []entity.Post{*post}, currentUserID(c))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to fetch post", []response.APIError{
+			{Code: "DB_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Post retrieved", summaries[0])
+}
+
+// GetPostViews returns the post's unique viewers, all-time or over the
+// window given in the "window" query parameter.
+func (pc *PostController) GetPostViews(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", []response.APIError{
+			{Field: "id", Code: "INVALID_UUID", Detail: err.Error()},
+		})
+		return
+	}
+
+	if _, err := pc.postUsecase.GetPostByID(c.Request.Context(), id); err != nil {
+		response.Error(c, http.StatusNotFound, "Post not found", []response.APIError{
+			{Code: "NOT_FOUND", Detail: err.Error()},
+		})
+		return
+	}
+
+	window := c.Query("window")
+	views, err := pc.viewUsecase.UniqueViews(c.Request.Context(), id, window)
+	if errors.Is(err, usecase.ErrInvalidWindow) {
+		response.Error(c, http.StatusBadRequest, "Invalid window", []response.APIError{
+			{Field: "window", Code: "INVALID_QUERY", Detail: "window must be one of: 1h, 24h, 7d"},
+		})
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to count views", []response.APIError{
+			{Code: "VIEWS_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Post views retrieved", gin.H{
+		"post_id":      id,
+		"window":       window,
+		"unique_views": views,
+	})
+}
+
+// viewerID identifies the reader for unique-view counting: the user when
+// authenticated, otherwise a fingerprint of their address. The user agent is
+// left out because a client can rotate it at will to count again.
+func viewerID(c *gin.Context) string {
+	if claims, ok := c.Get("user"); ok {
+		return "user:" + claims.(*jwt.Claims).UserID
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP()))
+	return "anon:" + hex.EncodeToString(sum[:16])
 }
 
+// currentUserID returns the authenticated user, or uuid.Nil when the request
+// is anonymous.
+func currentUserID(c *gin.Context) uuid.UUID {
+	claims, ok := c.Get("user")
+	if !ok {
+		return uuid.Nil
+	}
+	id, _ := uuid.Parse(claims.(*jwt.Claims).UserID)
+	return id
+}
+
+// maxSearchLength bounds the q query parameter of the post list.
+const maxSearchLength = 100
+
+// parsePostQuery reads the filter and sort query parameters of the post
+// list, responding with 400 if one is malformed.
+func parsePostQuery(c *gin.Context) (usecase.PostQuery, bool) {
+	query := usecase.PostQuery{Sort: c.Query("sort"), Text: c.Query("q")}
+	invalid := func(field, detail string) (usecase.PostQuery, bool) {
+		response.Error(c, http.StatusBadRequest, "Invalid "+field, []response.APIError{
+			{Field: field, Code: "INVALID_QUERY", Detail: detail},
+		})
+		return query, false
+	}
+
+	if raw := c.Query("author"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return invalid("author", "author must be a user ID")
+		}
+		query.AuthorID = id
+	}
+	for _, bound := range []struct {
+		field string
+		at    *time.Time
+	}{{"since", &query.Since}, {"until", &query.Until}} {
+		if raw := c.Query(bound.field); raw != "" {
+			at, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return invalid(bound.field, bound.field+" must be an RFC 3339 time")
+			}
+			*bound.at = at
+		}
+	}
+	if raw := c.Query("has_image"); raw != "" {
+		hasImage, err := strconv.ParseBool(raw)
+		if err != nil {
+			return invalid("has_image", "has_image must be true or false")
+		}
+		query.HasImage = &hasImage
+	}
+	if raw := c.Query("tag"); raw != "" {
+		tag, ok := hashtag.Normalize(raw)
+		if !ok {
+			return invalid("tag", "tag must be up to 50 letters, digits or underscores, not only digits")
+		}
+		query.Tag = tag
+	}
+	if len([]rune(query.Text)) > maxSearchLength {
+		return invalid("q", "q must be at most "+strconv.Itoa(maxSearchLength)+" characters")
+	}
+	return query, true
+}
+
+// listPostsError responds to an error of ListPosts that the request caused,
+// reporting whether it did.
+func listPostsError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSort):
+		response.Error(c, http.StatusBadRequest, "Invalid sort", []response.APIError{
+			{Field: "sort", Code: "INVALID_QUERY", Detail: "sort must be one of: new, old, top, trending, most_commented"},
+		})
+	case errors.Is(err, usecase.ErrInvalidRange):
+		response.Error(c, http.StatusBadRequest, "Invalid time range", []response.APIError{
+			{Field: "since", Code: "INVALID_QUERY", Detail: err.Error()},
+		})
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		response.Error(c, http.StatusBadRequest, "Invalid cursor", []response.APIError{
+			{Field: "cursor", Code: "INVALID_CURSOR", Detail: "cursor does not belong to this sort"},
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// GetAllPosts lists posts filtered by author, since, until, has_image, tag
+// and q, in the order sort names, and paged by cursor.
 func (pc *PostController) GetAllPosts(c *gin.Context) {
-	posts, err := pc.postUsecase.GetAllPosts(c.Request.Context())
+	query, ok := parsePostQuery(c)
+	if !ok {
+		return
+	}
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	posts, info, err := pc.postUsecase.ListPosts(c.Request.Context(), query, page)
+	if listPostsError(c, err) {
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to fetch posts", []response.APIError{
+			{Code: "DB_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	summaries, err := pc.summaryUsecase.Summarize(c.Request.Context(), posts, currentUserID(c))
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to fetch posts", []response.APIError{
 			{Code: "DB_ERROR", Detail: err.Error()},
 		})
 		return
 	}
-	response.Success(c, http.StatusOK, "Posts retrieved", posts)
+	response.Success(c, http.StatusOK, "Posts retrieved", summaries, pageMeta(info))
 }
 
 func (pc *PostController) UpdatePost(c *gin.Context) {
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"backend/internal/usecase"
+	"backend/pkg/hashtag"
+	"backend/pkg/response"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagController struct {
+	postUC     usecase.PostUsecase
+	trendingUC usecase.TrendingUsecase
+	summaryUC  usecase.PostSummaryUsecase
+}
+
+func NewTagController(postUC usecase.PostUsecase, trendingUC usecase.TrendingUsecase, summaryUC usecase.PostSummaryUsecase) *TagController {
+	return &TagController{postUC: postUC, trendingUC: trendingUC, summaryUC: summaryUC}
+}
+
+// GetTagPosts lists the posts tagged with the tag in the path, the k most
+// liked first, or newest first and paged by cursor with sort=new.
+func (tc *TagController) GetTagPosts(c *gin.Context) {
+	tag, ok := hashtag.Normalize(c.Param("tag"))
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid tag", []response.APIError{
+			{Field: "tag", Code: "INVALID_TAG", Detail: "tag must be up to 50 letters, digits or underscores, not only digits"},
+		})
+		return
+	}
+
+	switch c.DefaultQuery("sort", "top") {
+	case "top":
+		k, ok := parseK(c)
+		if !ok {
+			return
+		}
+		posts, err := tc.trendingUC.GetTagPosts(c.Request.Context(), tag, k, currentUserID(c))
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "Failed to fetch tagged posts", []response.APIError{
+				{Code: "TRENDING_ERROR", Detail: err.Error()},
+			})
+			return
+		}
+		response.Success(c, http.StatusOK, "Tagged posts retrieved", posts)
+	case "new":
+		page, ok := parsePage(c)
+		if !ok {
+			return
+		}
+		posts, info, err := tc.postUC.ListPosts(c.Request.Context(), usecase.PostQuery{Tag: tag}, page)
+		if listPostsError(c, err) {
+			return
+		}
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "Failed to fetch tagged posts", []response.APIError{
+				{Code: "DB_ERROR", Detail: err.Error()},
+			})
+			return
+		}
+		summaries, err := tc.summaryUC.Summarize(c.Request.Context(), posts, currentUserID(c))
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "Failed to fetch tagged posts", []response.APIError{
+				{Code: "DB_ERROR", Detail: err.Error()},
+			})
+			return
+		}
+		response.Success(c, http.StatusOK, "Tagged posts retrieved", summaries, pageMeta(info))
+	default:
+		response.Error(c, http.StatusBadRequest, "Invalid sort", []response.APIError{
+			{Field: "sort", Code: "INVALID_QUERY", Detail: "sort must be one of: top, new"},
+		})
+	}
+}
+
+// GetTrendingTags ranks hashtags by the likes of their posts, all-time or
+// over the window query parameter.
+func (tc *TagController) GetTrendingTags(c *gin.Context) {
+	k, ok := parseK(c)
+	if !ok {
+		return
+	}
+
+	tags, err := tc.trendingUC.GetTrendingTags(c.Request.Context(), k, c.Query("window"))
+	if errors.Is(err, usecase.ErrInvalidWindow) {
+		response.Error(c, http.StatusBadRequest, "Invalid window", []response.APIError{
+			{Field: "window", Code: "INVALID_QUERY", Detail: "window must be one of: 1h, 24h, 7d"},
+		})
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to fetch trending tags", []response.APIError{
+			{Code: "TRENDING_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Trending tags retrieved", tags)
+}
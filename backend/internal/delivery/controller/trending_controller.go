@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"backend/internal/usecase"
+	"backend/pkg/response"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTrendingK = 10
+	maxTrendingK     = 100
+
+	// trendingStreamHeartbeat keeps idle stream connections from being
+	// closed by proxies.
+	trendingStreamHeartbeat = 15 * time.Second
+)
+
+type TrendingController struct {
+	trendingUC usecase.TrendingUsecase
+	streamUC   usecase.TrendingStreamUsecase
+	snapshotUC usecase.TrendingSnapshotUsecase
+}
+
+func NewTrendingController(
+	trendingUC usecase.TrendingUsecase,
+	streamUC usecase.TrendingStreamUsecase,
+	snapshotUC usecase.TrendingSnapshotUsecase,
+) *TrendingController {
+	return &TrendingController{trendingUC: trendingUC, streamUC: streamUC, snapshotUC: snapshotUC}
+}
+
+// parseK reads the "k" query parameter, writing an error response and
+// returning false when it is invalid.
+func parseK(c *gin.Context) (int, bool) {
+	raw := c.Query("k")
+	if raw == "" {
+		return defaultTrendingK, true
+	}
+	k, err := strconv.Atoi(raw)
+	if err != nil || k < 1 || k > maxTrendingK {
+		response.Error(c, http.StatusBadRequest, "Invalid k", []response.APIError{
+			{Field: "k", Code: "INVALID_QUERY", Detail: "k must be an integer between 1 and 100"},
+		})
+		return 0, false
+	}
+	return k, true
+}
+
+func (tc *TrendingController) GetTrending(c *gin.Context) {
+	k, ok := parseK(c)
+	if !ok {
+		return
+	}
+
+	posts, err := tc.trendingUC.GetTrending(c.Request.Context(), usecase.TrendingQuery{
+		K:        k,
+		Algo:     c.Query("algo"),
+		Window:   c.Query("window"),
+		ViewerID: currentUserID(c),
+	})
+	if errors.Is(err, usecase.ErrInvalidAlgo) {
+		response.Error(c, http.StatusBadRequest, "Invalid algo", []response.APIError{
+			{Field: "algo", Code: "INVALID_QUERY", Detail: "algo must be one of: raw, decay, sketch, views, engagement"},
+		})
+		return
+	}
+	if errors.Is(err, usecase.ErrInvalidWindow) {
+		response.Error(c, http.StatusBadRequest, "Invalid window", []response.APIError{
+			{Field: "window", Code: "INVALID_QUERY", Detail: "window must be one of: 1h, 24h, 7d"},
+		})
+		return
+	}
+	if errors.Is(err, usecase.ErrWindowUnsupported) {
+		response.Error(c, http.StatusBadRequest, "Invalid window", []response.APIError{
+			{Field: "window", Code: "INVALID_QUERY", Detail: err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to fetch trending posts", []response.APIError{
+			{Code: "TRENDING_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Trending posts retrieved", posts)
+}
+
+// GetTrendingHistory returns the raw Top-K of a window as archived by the
+// latest snapshot taken at or before the "at" query parameter.
+func (tc *TrendingController) GetTrendingHistory(c *gin.Context) {
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid at", []response.APIError{
+			{Field: "at", Code: "INVALID_QUERY", Detail: "at must be an RFC3339 timestamp"},
+		})
+		return
+	}
+
+	history, err := tc.snapshotUC.GetHistory(c.Request.Context(), at, c.Query("window"))
+	if errors.Is(err, usecase.ErrInvalidWindow) {
+		response.Error(c, http.StatusBadRequest, "Invalid window", []response.APIError{
+			{Field: "window", Code: "INVALID_QUERY", Detail: "window must be one of: 1h, 24h, 7d"},
+		})
+		return
+	}
+	if errors.Is(err, usecase.ErrNoSnapshot) {
+		response.Error(c, http.StatusNotFound, "No trending snapshot found", []response.APIError{
+			{Field: "at", Code: "NOT_FOUND", Detail: err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to fetch trending history", []response.APIError{
+			{Code: "TRENDING_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Trending history retrieved", history)
+}
+
+// ExplainTrending shows how a post's trending scores are made up and whether
+// the stored counts behind them have drifted from Postgres.
+func (tc *TrendingController) ExplainTrending(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", []response.APIError{
+			{Field: "id", Code: "INVALID_UUID", Detail: err.Error()},
+		})
+		return
+	}
+
+	explanation, err := tc.trendingUC.Explain(c.Request.Context(), id)
+	if errors.Is(err, usecase.ErrPostNotFound) {
+		response.Error(c, http.StatusNotFound, "Post not found", []response.APIError{
+			{Code: "NOT_FOUND", Detail: err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to explain trending scores", []response.APIError{
+			{Code: "TRENDING_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Trending explanation retrieved", explanation)
+}
+
+// GetRising returns the posts gaining engagement fastest relative to their
+// own recent baseline.
+func (tc *TrendingController) GetRising(c *gin.Context) {
+	k, ok := parseK(c)
+	if !ok {
+		return
+	}
+
+	posts, err := tc.trendingUC.GetRising(c.Request.Context(), k, currentUserID(c))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to fetch rising posts", []response.APIError{
+			{Code: "TRENDING_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Rising posts retrieved", posts)
+}
+
+// GetTrendingAuthors ranks authors by the likes their posts received,
+// all-time or over the window query parameter. Flagged likes are discounted
+// here, so an author's score can be below the raw karma on their profile.
+func (tc *TrendingController) GetTrendingAuthors(c *gin.Context) {
+	k, ok := parseK(c)
+	if !ok {
+		return
+	}
+
+	authors, err := tc.trendingUC.GetTrendingAuthors(c.Request.Context(), k, c.Query("window"))
+	if errors.Is(err, usecase.ErrInvalidWindow) {
+		response.Error(c, http.StatusBadRequest, "Invalid window", []response.APIError{
+			{Field: "window", Code: "INVALID_QUERY", Detail: "window must be one of: 1h, 24h, 7d"},
+		})
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to fetch trending authors", []response.APIError{
+			{Code: "TRENDING_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+	response.Success(c, http.StatusOK, "Trending authors retrieved", authors)
+}
+
+// StreamTrending pushes the all-time Top-K as Server-Sent Events whenever the
+// ranking changes. Reconnecting clients send Last-Event-ID to receive the
+// snapshots they missed.
+func (tc *TrendingController) StreamTrending(c *gin.Context) {
+	var lastEventID int64
+	if raw := c.GetHeader("Last-Event-ID"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			response.Error(c, http.StatusBadRequest, "Invalid Last-Event-ID", []response.APIError{
+				{Field: "Last-Event-ID", Code: "INVALID_HEADER", Detail: "Last-Event-ID must be a non-negative integer"},
+			})
+			return
+		}
+		lastEventID = parsed
+	}
+
+	events, err := tc.streamUC.Subscribe(c.Request.Context(), lastEventID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to subscribe to trending posts", []response.APIError{
+			{Code: "TRENDING_ERROR", Detail: err.Error()},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(trendingStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			fmt.Fprintf(w, "id: %d\nevent: trending\ndata: %s\n\n", event.ID, event.Data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		return true
+	})
+}
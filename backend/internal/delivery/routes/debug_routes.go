@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"expvar"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugRoutes serves runtime metrics (trending drift counters, memstats). They
+// expose process internals, so they belong on an internal listener only.
+func DebugRoutes(router *gin.Engine) {
+	router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+}
@@ -5,11 +5,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func LikeRoutes(r *gin.RouterGroup, likeController *controller.LikeController, authMiddleware gin.HandlerFunc) {
+func LikeRoutes(r *gin.RouterGroup, likeController *controller.LikeController, authMiddleware gin.HandlerFunc, rateLimitMiddleware gin.HandlerFunc) {
 	auth := r.Group("/")
 	auth.Use(authMiddleware)
 	{
-		auth.POST("/:post_id", likeController.ToggleLike)    
+		auth.POST("/:post_id", rateLimitMiddleware, likeController.ToggleLike)    
+		auth.PUT("/:post_id", rateLimitMiddleware, likeController.LikePost)
+		auth.DELETE("/:post_id", rateLimitMiddleware, likeController.UnlikePost)
 		auth.GET("/:post_id", likeController.GetLikesByPost) 
 	}
 }
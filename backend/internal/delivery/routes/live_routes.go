@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"backend/internal/delivery/controller"
+	"github.com/gin-gonic/gin"
+)
+
+func LiveRoutes(r *gin.RouterGroup, liveController *controller.LiveController, authMiddleware gin.HandlerFunc) {
+	r.GET("/:id/live", authMiddleware, liveController.PostLive)
+}
@@ -5,9 +5,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterPostRoutes(r *gin.RouterGroup, postController *controller.PostController, authMiddleware gin.HandlerFunc) {
-	r.GET("/", postController.GetAllPosts)
-	r.GET("/:id", postController.GetPostByID)
+func RegisterPostRoutes(r *gin.RouterGroup, postController *controller.PostController, authMiddleware, optionalAuthMiddleware, viewRateLimitMiddleware gin.HandlerFunc) {
+	r.GET("/", optionalAuthMiddleware, postController.GetAllPosts)
+	// Each read records a view, so it is limited per IP like the likes.
+	r.GET("/:id", optionalAuthMiddleware, viewRateLimitMiddleware, postController.GetPostByID)
+	r.GET("/:id/views", postController.GetPostViews)
 
 	auth := r.Group("/")
 	auth.Use(authMiddleware)
@@ -12,18 +12,38 @@ func SetupRoutes(
 	postController *controller.PostController,
 	likeController *controller.LikeController,
 	commentController *controller.CommentController,
+	trendingController *controller.TrendingController,
+	liveController *controller.LiveController,
+	tagController *controller.TagController,
 	authMiddleware gin.HandlerFunc,
+	liveAuthMiddleware gin.HandlerFunc,
+	optionalAuthMiddleware gin.HandlerFunc,
+	likeRateLimitMiddleware gin.HandlerFunc,
+	viewRateLimitMiddleware gin.HandlerFunc,
+	explainRateLimitMiddleware gin.HandlerFunc,
 ) {
 	api := router.Group("/api/v1")
 
 	// User routes
 	UserRoutes(api.Group("/users"), userController, authMiddleware)
 
+	// Trending author routes
+	TrendingAuthorRoutes(api.Group("/users"), trendingController)
+
 	// Post routes
-	RegisterPostRoutes(api.Group("/posts"), postController, authMiddleware)
+	RegisterPostRoutes(api.Group("/posts"), postController, authMiddleware, optionalAuthMiddleware, viewRateLimitMiddleware)
+
+	// Trending routes
+	TrendingRoutes(api.Group("/posts"), trendingController, authMiddleware, optionalAuthMiddleware, explainRateLimitMiddleware)
+
+	// Live post updates over WebSocket
+	LiveRoutes(api.Group("/posts"), liveController, liveAuthMiddleware)
 
 	// Like routes
-	LikeRoutes(api.Group("/likes"), likeController, authMiddleware)
+	LikeRoutes(api.Group("/likes"), likeController, authMiddleware, likeRateLimitMiddleware)
+
+	// Tag routes
+	TagRoutes(api.Group("/tags"), tagController, optionalAuthMiddleware)
 
 	// Comment routes
 	CommentRoutes(api.Group("/comments"), commentController, authMiddleware)
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"backend/internal/delivery/controller"
+	"github.com/gin-gonic/gin"
+)
+
+func TagRoutes(r *gin.RouterGroup, tagController *controller.TagController, optionalAuthMiddleware gin.HandlerFunc) {
+	r.GET("/trending", tagController.GetTrendingTags)
+	r.GET("/:tag/posts", optionalAuthMiddleware, tagController.GetTagPosts)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"backend/internal/delivery/controller"
+	"github.com/gin-gonic/gin"
+)
+
+func TrendingRoutes(r *gin.RouterGroup, trendingController *controller.TrendingController, authMiddleware, optionalAuthMiddleware, explainRateLimitMiddleware gin.HandlerFunc) {
+	r.GET("/trending", optionalAuthMiddleware, trendingController.GetTrending)
+	r.GET("/trending/stream", trendingController.StreamTrending)
+	r.GET("/trending/history", trendingController.GetTrendingHistory)
+	r.GET("/rising", optionalAuthMiddleware, trendingController.GetRising)
+	// Explaining recomputes every window from Postgres, so it is kept to
+	// signed-in users and rate limited.
+	r.GET("/:id/trending/explain", authMiddleware, explainRateLimitMiddleware, trendingController.ExplainTrending)
+}
+
+func TrendingAuthorRoutes(r *gin.RouterGroup, trendingController *controller.TrendingController) {
+	r.GET("/trending", trendingController.GetTrendingAuthors)
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LikeFlag marks a like the fraud checks found suspicious. The like itself is
+// kept; Discount is the share of it, between 0 and 1, left out of trending.
+type LikeFlag struct {
+	LikeID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	LikedAt   time.Time `gorm:"not null;index"`
+	Discount  float64   `gorm:"not null"`
+	Reasons   string    `gorm:"not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEntry is a domain event saved in the same transaction as the change
+// that caused it, waiting to be relayed to the event bus.
+type OutboxEntry struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	EventType     string    `gorm:"not null"`
+	Payload       []byte    `gorm:"type:jsonb;not null"`
+	CreatedAt     time.Time
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_pending,where:delivered_at IS NULL"`
+	DeliveredAt   *time.Time `gorm:"index"`
+	LastError     string
+}
+
+func (OutboxEntry) TableName() string {
+	return "outbox"
+}
@@ -7,28 +7,34 @@ import (
 )
 
 type Post struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;index:idx_posts_keyset,priority:2;index:idx_posts_top,priority:3;index:idx_posts_most_commented,priority:3"`
 	AuthorID  uuid.UUID `gorm:"type:uuid;not null"`
 	Title     string    `gorm:"not null"`
 	Content   string    `gorm:"type:text"`
 	ImageURL  string
-	CreatedAt time.Time
+	CreatedAt time.Time `gorm:"index:idx_posts_keyset,priority:1;index:idx_posts_top,priority:2;index:idx_posts_most_commented,priority:2"`
 	UpdatedAt time.Time
+	// LikeCount and CommentCount are kept in step with the likes and
+	// comments tables by the repositories that write them.
+	LikeCount    int64 `gorm:"not null;default:0;index:idx_posts_top,priority:1"`
+	CommentCount int64 `gorm:"not null;default:0;index:idx_posts_most_commented,priority:1"`
 	Likes    []Like    `gorm:"foreignKey:PostID"`
 	Comments []Comment `gorm:"foreignKey:PostID"`
+	Tags     []Tag     `gorm:"many2many:post_tags;constraint:OnDelete:CASCADE"`
+	UniqueViews int64 `gorm:"-"`
 }
 
 type Like struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index"` 
-	PostID    uuid.UUID `gorm:"type:uuid;not null;index"` 
-	CreatedAt time.Time
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;index:idx_likes_post_keyset,priority:3"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_likes_user_post"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_likes_user_post;index:idx_likes_post_keyset,priority:1"`
+	CreatedAt time.Time `gorm:"index:idx_likes_post_keyset,priority:2"`
 }
 
 type Comment struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey;index:idx_comments_post_keyset,priority:3"`
 	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
-	PostID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;index;index:idx_comments_post_keyset,priority:1"`
 	Content   string    `gorm:"type:text;not null"`
-	CreatedAt time.Time
+	CreatedAt time.Time `gorm:"index:idx_comments_post_keyset,priority:2"`
 }
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrendingSnapshot is one row of a Top-K ranking as it stood at TakenAt.
+// Window is the trending window the ranking covers, empty for all-time.
+type TrendingSnapshot struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	TakenAt time.Time `gorm:"not null;uniqueIndex:idx_trending_snapshots_slot,priority:2;index"`
+	Window  string    `gorm:"column:time_window;not null;uniqueIndex:idx_trending_snapshots_slot,priority:1"`
+	Rank    int       `gorm:"not null;uniqueIndex:idx_trending_snapshots_slot,priority:3"`
+	PostID  uuid.UUID `gorm:"type:uuid;not null"`
+	Score   float64   `gorm:"not null"`
+}
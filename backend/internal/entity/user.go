@@ -12,6 +12,11 @@ type User struct {
 	Email         string    `gorm:"uniqueIndex;not null"`
 	PasswordHash  string    `gorm:"not null"`
 	ProfilePicURL string
+	// Karma is the raw number of likes on the user's existing posts, moved in
+	// the same transaction as the like rows. Unlike the trending authors
+	// board it is not discounted for flagged likes, and buffered likes count
+	// once they are flushed. Deleting a post takes its likes away.
+	Karma         int64 `gorm:"not null;default:0"`
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	Posts         []Post `gorm:"foreignKey:AuthorID;references:ID"`
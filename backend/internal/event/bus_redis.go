@@ -0,0 +1,184 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	eventStream       = "events"
+	eventStreamMaxLen = 100000
+	eventReadCount    = 100
+	eventReadBlock    = 5 * time.Second
+	// eventClaimIdle is how long a delivered event may stay unacknowledged
+	// before it is handed out again, whether its handler failed or its
+	// consumer died.
+	eventClaimIdle   = time.Minute
+	eventMaxDelivery = 5
+)
+
+// redisBus appends events to one Redis stream. Each subscriber name is a
+// consumer group, so every event reaches each subscriber once across all
+// instances, and an event is only acknowledged once its handler succeeds.
+type redisBus struct {
+	rdb         *redis.Client
+	consumer    string
+	subscribers []subscriber
+}
+
+func NewRedisBus(rdb *redis.Client) Bus {
+	host, _ := os.Hostname()
+	return &redisBus{
+		rdb:      rdb,
+		consumer: fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+}
+
+func (b *redisBus) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	key, _ := KeyFrom(ctx)
+	return b.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: eventStream,
+		MaxLen: eventStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":    string(e.Type()),
+			"key":     key,
+			"payload": payload,
+		},
+	}).Err()
+}
+
+func (b *redisBus) Subscribe(name string, handler Handler, types ...Type) {
+	b.subscribers = append(b.subscribers, newSubscriber(name, handler, types))
+}
+
+func (b *redisBus) Start(ctx context.Context) error {
+	for _, s := range b.subscribers {
+		// A new group starts at the end of the stream; an existing one
+		// resumes where it left off.
+		err := b.rdb.XGroupCreateMkStream(ctx, eventStream, s.name, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return err
+		}
+	}
+	for _, s := range b.subscribers {
+		go b.consume(ctx, s)
+	}
+	return nil
+}
+
+func (b *redisBus) consume(ctx context.Context, s subscriber) {
+	lastClaim := time.Now()
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= eventClaimIdle {
+			b.reclaim(ctx, s)
+			lastClaim = time.Now()
+		}
+
+		streams, err := b.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.name,
+			Consumer: b.consumer,
+			Streams:  []string{eventStream, ">"},
+			Count:    eventReadCount,
+			Block:    eventReadBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("⚠️ Failed to read events for %s: %v", s.name, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				b.handle(ctx, s, msg)
+			}
+		}
+	}
+}
+
+// reclaim takes over events that have waited too long for an acknowledgement
+// and retries them, giving up on those that keep failing.
+func (b *redisBus) reclaim(ctx context.Context, s subscriber) {
+	pending, err := b.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: eventStream,
+		Group:  s.name,
+		Idle:   eventClaimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  eventReadCount,
+	}).Result()
+	if err != nil {
+		log.Printf("⚠️ Failed to list pending events for %s: %v", s.name, err)
+		return
+	}
+
+	var ids []string
+	for _, p := range pending {
+		if p.RetryCount >= eventMaxDelivery {
+			log.Printf("❌ Giving up on event %s for %s after %d deliveries", p.ID, s.name, p.RetryCount)
+			b.rdb.XAck(ctx, eventStream, s.name, p.ID)
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	messages, err := b.rdb.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   eventStream,
+		Group:    s.name,
+		Consumer: b.consumer,
+		MinIdle:  eventClaimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		log.Printf("⚠️ Failed to claim pending events for %s: %v", s.name, err)
+		return
+	}
+	for _, msg := range messages {
+		b.handle(ctx, s, msg)
+	}
+}
+
+func (b *redisBus) handle(ctx context.Context, s subscriber, msg redis.XMessage) {
+	t, _ := msg.Values["type"].(string)
+	payload, _ := msg.Values["payload"].(string)
+	if key, _ := msg.Values["key"].(string); key != "" {
+		ctx = WithKey(ctx, key)
+	}
+
+	if s.wants(Type(t)) {
+		e, err := Decode(Type(t), []byte(payload))
+		if err != nil {
+			log.Printf("❌ Dropping malformed event %s: %v", msg.ID, err)
+		} else if err := s.handler(ctx, e); err != nil {
+			log.Printf("⚠️ Event subscriber %s failed on %s, will retry: %v", s.name, t, err)
+			return
+		}
+	}
+	if err := b.rdb.XAck(ctx, eventStream, s.name, msg.ID).Err(); err != nil {
+		log.Printf("⚠️ Failed to acknowledge event %s for %s: %v", msg.ID, s.name, err)
+	}
+}
@@ -0,0 +1,48 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// syncBus delivers each event to its subscribers in the publishing goroutine,
+// in registration order, before Publish returns. Publish fails if any
+// subscriber does, so the caller can retry the whole delivery; subscribers
+// that are not idempotent should be wrapped with Dedup.
+type syncBus struct {
+	mu          sync.RWMutex
+	subscribers []subscriber
+}
+
+func NewSyncBus() Bus {
+	return &syncBus{}
+}
+
+func (b *syncBus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var errs []error
+	for _, s := range b.subscribers {
+		if !s.wants(e.Type()) {
+			continue
+		}
+		// One failing side effect must not keep the others from running.
+		if err := s.handler(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *syncBus) Subscribe(name string, handler Handler, types ...Type) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, newSubscriber(name, handler, types))
+}
+
+func (b *syncBus) Start(ctx context.Context) error {
+	return nil
+}
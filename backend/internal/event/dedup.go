@@ -0,0 +1,102 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DedupStore remembers which delivery keys a consumer has handled.
+type DedupStore interface {
+	Seen(ctx context.Context, key string) (bool, error)
+	// Remember marks key as handled for ttl.
+	Remember(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// Dedup wraps a handler so that an event delivered again under the same key
+// is skipped once name has handled it. The key is only remembered after the
+// handler succeeds, so a failed attempt is retried; two deliveries racing
+// each other may both run.
+func Dedup(name string, store DedupStore, ttl time.Duration, handler Handler) Handler {
+	return func(ctx context.Context, e Event) error {
+		key, ok := KeyFrom(ctx)
+		if !ok {
+			return handler(ctx, e)
+		}
+		key = name + ":" + key
+
+		seen, err := store.Seen(ctx, key)
+		if err != nil {
+			return err
+		}
+		if seen {
+			return nil
+		}
+		if err := handler(ctx, e); err != nil {
+			return err
+		}
+		return store.Remember(ctx, key, ttl)
+	}
+}
+
+const dedupKeyPrefix = "events:dedup:"
+
+type dedupStoreRedis struct {
+	rdb *redis.Client
+}
+
+func NewDedupStoreRedis(rdb *redis.Client) DedupStore {
+	return &dedupStoreRedis{rdb: rdb}
+}
+
+func (s *dedupStoreRedis) Seen(ctx context.Context, key string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, dedupKeyPrefix+key).Result()
+	return n > 0, err
+}
+
+func (s *dedupStoreRedis) Remember(ctx context.Context, key string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, dedupKeyPrefix+key, "1", ttl).Err()
+}
+
+type dedupStoreMemory struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewDedupStoreMemory() DedupStore {
+	return &dedupStoreMemory{expires: make(map[string]time.Time)}
+}
+
+func (s *dedupStoreMemory) Seen(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.expires[key]
+	if ok && time.Now().After(expiresAt) {
+		delete(s.expires, key)
+		return false, nil
+	}
+	return ok, nil
+}
+
+func (s *dedupStoreMemory) Remember(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// Sweep expired keys now and then so the map does not grow without
+	// bound.
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, expiresAt := range s.expires {
+			if now.After(expiresAt) {
+				delete(s.expires, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.expires[key] = now.Add(ttl)
+	return nil
+}
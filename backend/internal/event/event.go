@@ -0,0 +1,206 @@
+// Package event carries domain events from the usecases that cause them to
+// the side effects that react to them.
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+const (
+	TypePostCreated    Type = "post.created"
+	TypePostDeleted    Type = "post.deleted"
+	TypeLikeAdded      Type = "like.added"
+	TypeLikeRemoved    Type = "like.removed"
+	TypeCommentCreated Type = "comment.created"
+	TypeCommentDeleted Type = "comment.deleted"
+	TypeUserRegistered Type = "user.registered"
+	TypePostViewed     Type = "post.viewed"
+	TypePostRetagged   Type = "post.retagged"
+)
+
+type Event interface {
+	Type() Type
+}
+
+type PostCreated struct {
+	PostID   uuid.UUID `json:"post_id"`
+	AuthorID uuid.UUID `json:"author_id"`
+	At       time.Time `json:"at"`
+}
+
+// PostDeleted carries the author and tags the post had, which are gone with
+// it.
+type PostDeleted struct {
+	PostID   uuid.UUID `json:"post_id"`
+	AuthorID uuid.UUID `json:"author_id"`
+	Tags     []string  `json:"tags,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// PostRetagged is published when editing a post changes its hashtags.
+type PostRetagged struct {
+	PostID  uuid.UUID `json:"post_id"`
+	Added   []string  `json:"added,omitempty"`
+	Removed []string  `json:"removed,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// LikeAdded carries the author of the liked post, and the share of the like,
+// between 0 and 1, that fraud checks leave out of trending.
+type LikeAdded struct {
+	LikeID   uuid.UUID `json:"like_id"`
+	PostID   uuid.UUID `json:"post_id"`
+	AuthorID uuid.UUID `json:"author_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	LikedAt  time.Time `json:"liked_at"`
+	Discount float64   `json:"discount,omitempty"`
+}
+
+// LikeRemoved carries the time the removed like was made, so consumers that
+// bucket likes by time can take it out of the right bucket, and the discount
+// it was added with.
+type LikeRemoved struct {
+	LikeID   uuid.UUID `json:"like_id"`
+	PostID   uuid.UUID `json:"post_id"`
+	AuthorID uuid.UUID `json:"author_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	LikedAt  time.Time `json:"liked_at"`
+	Discount float64   `json:"discount,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+type CommentCreated struct {
+	CommentID uuid.UUID `json:"comment_id"`
+	PostID    uuid.UUID `json:"post_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	At        time.Time `json:"at"`
+}
+
+// CommentDeleted carries the time the deleted comment was made, like
+// LikeRemoved does for likes.
+type CommentDeleted struct {
+	CommentID uuid.UUID `json:"comment_id"`
+	PostID    uuid.UUID `json:"post_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	At        time.Time `json:"at"`
+}
+
+type UserRegistered struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	At       time.Time `json:"at"`
+}
+
+// PostViewed is published the first time a viewer is counted on a post. It
+// is best effort and goes straight to the bus rather than through the outbox.
+type PostViewed struct {
+	PostID uuid.UUID `json:"post_id"`
+	At     time.Time `json:"at"`
+}
+
+func (PostCreated) Type() Type    { return TypePostCreated }
+func (PostDeleted) Type() Type    { return TypePostDeleted }
+func (LikeAdded) Type() Type      { return TypeLikeAdded }
+func (LikeRemoved) Type() Type    { return TypeLikeRemoved }
+func (CommentCreated) Type() Type { return TypeCommentCreated }
+func (CommentDeleted) Type() Type { return TypeCommentDeleted }
+func (UserRegistered) Type() Type { return TypeUserRegistered }
+func (PostViewed) Type() Type     { return TypePostViewed }
+func (PostRetagged) Type() Type   { return TypePostRetagged }
+
+// Handler reacts to one event. A returned error fails the delivery so it is
+// retried: the sync backend returns it from Publish, and the Redis backend
+// redelivers the event later.
+type Handler func(ctx context.Context, e Event) error
+
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+type Bus interface {
+	Publisher
+	// Subscribe registers handler under name for the given event types, or
+	// for every type if none are given. Subscribers must be registered
+	// before Start.
+	Subscribe(name string, handler Handler, types ...Type)
+	// Start begins delivering events to subscribers until ctx is done.
+	Start(ctx context.Context) error
+}
+
+type subscriber struct {
+	name    string
+	handler Handler
+	types   map[Type]bool
+}
+
+func newSubscriber(name string, handler Handler, types []Type) subscriber {
+	s := subscriber{name: name, handler: handler}
+	if len(types) > 0 {
+		s.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+	return s
+}
+
+func (s subscriber) wants(t Type) bool {
+	return s.types == nil || s.types[t]
+}
+
+// Decode rebuilds an event of type t from its JSON encoding.
+func Decode(t Type, data []byte) (Event, error) {
+	switch t {
+	case TypePostCreated:
+		return decodeAs[PostCreated](data)
+	case TypePostDeleted:
+		return decodeAs[PostDeleted](data)
+	case TypeLikeAdded:
+		return decodeAs[LikeAdded](data)
+	case TypeLikeRemoved:
+		return decodeAs[LikeRemoved](data)
+	case TypeCommentCreated:
+		return decodeAs[CommentCreated](data)
+	case TypeCommentDeleted:
+		return decodeAs[CommentDeleted](data)
+	case TypeUserRegistered:
+		return decodeAs[UserRegistered](data)
+	case TypePostViewed:
+		return decodeAs[PostViewed](data)
+	case TypePostRetagged:
+		return decodeAs[PostRetagged](data)
+	default:
+		return nil, fmt.Errorf("unknown event type %q", t)
+	}
+}
+
+func decodeAs[E Event](data []byte) (Event, error) {
+	var e E
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+type keyCtx struct{}
+
+// WithKey attaches the delivery key of the event being published. Every
+// delivery of the same event carries the same key, so consumers that are not
+// idempotent can skip repeats.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyCtx{}, key)
+}
+
+// KeyFrom returns the delivery key set by WithKey.
+func KeyFrom(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyCtx{}).(string)
+	return key, ok && key != ""
+}
@@ -42,3 +42,33 @@ func AuthMiddleware(jwtService jwt.JWTService) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// WebSocketAuthMiddleware authenticates like AuthMiddleware but also accepts
+// the access token in the "token" query parameter, because browsers cannot
+// set headers on a WebSocket handshake.
+func WebSocketAuthMiddleware(jwtService jwt.JWTService) gin.HandlerFunc {
+	auth := AuthMiddleware(jwtService)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		auth(c)
+	}
+}
+
+// OptionalAuthMiddleware sets "user" like AuthMiddleware when the request
+// carries a valid Bearer token, and otherwise lets it through anonymously.
+func OptionalAuthMiddleware(jwtService jwt.JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+			token, claims, err := jwtService.ValidateToken(parts[1])
+			if err == nil && token.Valid {
+				c.Set("user", claims)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/jwt"
+	"backend/pkg/response"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rateLimitCheck struct {
+	key   string
+	limit int
+}
+
+// RateLimitMiddleware limits the requests to the routes it guards per
+// authenticated user and per client IP, each to its own limit per window. A
+// limit of 0 turns that check off. It must run after AuthMiddleware for the
+// per-user limit to apply. If the limiter is unavailable requests are let
+// through.
+func RateLimitMiddleware(limiter repository.RateLimiter, name string, perUser, perIP int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var checks []rateLimitCheck
+		if claims, ok := c.Get("user"); ok && perUser > 0 {
+			checks = append(checks, rateLimitCheck{name + ":user:" + claims.(*jwt.Claims).UserID, perUser})
+		}
+		if perIP > 0 {
+			checks = append(checks, rateLimitCheck{name + ":ip:" + c.ClientIP(), perIP})
+		}
+
+		for _, check := range checks {
+			allowed, err := limiter.Allow(c.Request.Context(), check.key, check.limit, window)
+			if err != nil {
+				log.Printf("⚠️ Rate limiter unavailable for %s: %v", name, err)
+				break
+			}
+			if !allowed {
+				retryAfter := time.Until(time.Now().Truncate(window).Add(window))
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				response.Error(c, http.StatusTooManyRequests, "Too many requests", []response.APIError{
+					{Code: "RATE_LIMITED", Detail: "Rate limit exceeded, try again later"},
+				})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
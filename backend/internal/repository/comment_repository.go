@@ -2,18 +2,27 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"backend/internal/entity"
+	"backend/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CommentRepository interface {
 	Create(ctx context.Context, comment *entity.Comment) error
 	Update(ctx context.Context, comment *entity.Comment) error
 	Delete(ctx context.Context, commentID uuid.UUID) error
-	FindByPostID(ctx context.Context, postID uuid.UUID) ([]entity.Comment, error)
+	// FindByPostID returns one page of the comments on a post, newest first.
+	FindByPostID(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Comment, pagination.Info, error)
 	GetCommentByID(ctx context.Context, id uuid.UUID) (*entity.Comment, error)
+	CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error)
+	CountByPostIDSince(ctx context.Context, postID uuid.UUID, since time.Time) (int64, error)
+	CountByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	CountByPost(ctx context.Context) ([]PostCount, error)
+	CountByBucket(ctx context.Context, since time.Time, size time.Duration) ([]BucketCount, error)
 }
 
 type commentRepositoryGorm struct {
@@ -28,27 +37,86 @@ func (r *commentRepositoryGorm) Create(ctx context.Context, comment *entity.Comm
 	if comment.ID == uuid.Nil {
 		comment.ID = uuid.New()
 	}
-	return r.db.WithContext(ctx).Create(comment).Error
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return addPostCount(tx, comment.PostID, "comment_count", 1)
+	})
 }
 
 func (r *commentRepositoryGorm) Update(ctx context.Context, comment *entity.Comment) error {
-	return r.db.WithContext(ctx).Save(comment).Error
+	return conn(ctx, r.db).Save(comment).Error
 }
 
 func (r *commentRepositoryGorm) Delete(ctx context.Context, commentID uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&entity.Comment{}, "id = ?", commentID).Error
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var deleted []entity.Comment
+		err := tx.
+			Clauses(clause.Returning{}).
+			Where("id = ?", commentID).
+			Delete(&deleted).Error
+		if err != nil || len(deleted) == 0 {
+			return err
+		}
+		return addPostCount(tx, deleted[0].PostID, "comment_count", -1)
+	})
 }
 
-func (r *commentRepositoryGorm) FindByPostID(ctx context.Context, postID uuid.UUID) ([]entity.Comment, error) {
+func (r *commentRepositoryGorm) FindByPostID(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Comment, pagination.Info, error) {
 	var comments []entity.Comment
-	err := r.db.WithContext(ctx).Where("post_id = ?", postID).Find(&comments).Error
-	return comments, err
+	err := keyset(conn(ctx, r.db), "comments", page).Where("post_id = ?", postID).Find(&comments).Error
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+	comments, info := pagination.Page(comments, page, func(c entity.Comment) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	return comments, info, nil
 }
 
 func (r *commentRepositoryGorm) GetCommentByID(ctx context.Context, id uuid.UUID) (*entity.Comment, error) {
     var comment entity.Comment
-    if err := r.db.WithContext(ctx).First(&comment, "id = ?", id).Error; err != nil {
+    if err := conn(ctx, r.db).First(&comment, "id = ?", id).Error; err != nil {
         return nil, err
     }
     return &comment, nil
 }
+
+func (r *commentRepositoryGorm) CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error) {
+	counts, err := postCountsByID(conn(ctx, r.db), "comment_count", []uuid.UUID{postID})
+	return counts[postID], err
+}
+
+func (r *commentRepositoryGorm) CountByPostIDSince(ctx context.Context, postID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.Comment{}).
+		Where("post_id = ? AND created_at > ?", postID, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *commentRepositoryGorm) CountByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := countByPost(conn(ctx, r.db).Model(&entity.Comment{}).Where("post_id IN ?", postIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *commentRepositoryGorm) CountByPost(ctx context.Context) ([]PostCount, error) {
+	return countByPost(conn(ctx, r.db).Model(&entity.Comment{}))
+}
+
+func (r *commentRepositoryGorm) CountByBucket(ctx context.Context, since time.Time, size time.Duration) ([]BucketCount, error) {
+	return countByBucket(conn(ctx, r.db).Model(&entity.Comment{}), since, size)
+}
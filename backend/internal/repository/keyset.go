@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"fmt"
+
+	"backend/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// keyset narrows db to the page p of table, newest first, in the fetch order
+// pagination.Page expects and with the extra row it needs.
+func keyset(db *gorm.DB, table string, p pagination.Params) *gorm.DB {
+	return keysetBy(db, table, p, nil, false)
+}
+
+// leadKey is an expression that orders rows ahead of their (created_at, id),
+// with the same expression for the position a cursor holds.
+type leadKey struct {
+	row    clause.Expr
+	cursor func(c *pagination.Cursor) clause.Expr
+}
+
+// keysetBy is keyset for an order led by lead when it is not nil, and oldest
+// first when asc is set.
+func keysetBy(db *gorm.DB, table string, p pagination.Params, lead *leadKey, asc bool) *gorm.DB {
+	if p.Cursor != nil && p.Cursor.Backward {
+		asc = !asc
+	}
+	op, dir := "<", "DESC"
+	if asc {
+		op, dir = ">", "ASC"
+	}
+
+	if c := p.Cursor; c != nil {
+		cols := fmt.Sprintf("%s.created_at, %s.id", table, table)
+		vals := "?, ?"
+		var vars []interface{}
+		if lead != nil {
+			at := lead.cursor(c)
+			cols = lead.row.SQL + ", " + cols
+			vals = at.SQL + ", " + vals
+			vars = append(append(vars, lead.row.Vars...), at.Vars...)
+		}
+		vars = append(vars, c.CreatedAt, c.ID)
+		db = db.Where(fmt.Sprintf("(%s) %s (%s)", cols, op, vals), vars...)
+	}
+	order := fmt.Sprintf("%s.created_at %s, %s.id %s", table, dir, table, dir)
+	if lead != nil {
+		// A later Order would replace an expression, so the lead and the
+		// columns after it are one.
+		return db.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                lead.row.SQL + " " + dir + ", " + order,
+			Vars:               lead.row.Vars,
+			WithoutParentheses: true,
+		}}).Limit(p.Limit + 1)
+	}
+	return db.Order(order).Limit(p.Limit + 1)
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BucketScore is the total a post collected in the time bucket starting at
+// Start.
+type BucketScore struct {
+	PostID uuid.UUID
+	Start  time.Time
+	Score  float64
+}
+
+type LikeFlagRepository interface {
+	Add(ctx context.Context, flag *entity.LikeFlag) error
+	// Remove deletes the flag of a like and returns it, or nil if the like
+	// was not flagged.
+	Remove(ctx context.Context, likeID uuid.UUID) (*entity.LikeFlag, error)
+	// DiscountByPost sums the discounts of each post's flagged likes.
+	DiscountByPost(ctx context.Context) ([]PostScore, error)
+	DiscountByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]float64, error)
+	// DiscountSince sums the discounts of a post's flagged likes made at or
+	// after since.
+	DiscountSince(ctx context.Context, postID uuid.UUID, since time.Time) (float64, error)
+	// DiscountByBucket sums discounts of likes made after since per post and
+	// time bucket of the given size.
+	DiscountByBucket(ctx context.Context, since time.Time, size time.Duration) ([]BucketScore, error)
+}
+
+type likeFlagRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewLikeFlagRepositoryGorm(db *gorm.DB) LikeFlagRepository {
+	return &likeFlagRepositoryGorm{db: db}
+}
+
+func (r *likeFlagRepositoryGorm) Add(ctx context.Context, flag *entity.LikeFlag) error {
+	return conn(ctx, r.db).Create(flag).Error
+}
+
+func (r *likeFlagRepositoryGorm) Remove(ctx context.Context, likeID uuid.UUID) (*entity.LikeFlag, error) {
+	var deleted []entity.LikeFlag
+	err := conn(ctx, r.db).
+		Clauses(clause.Returning{}).
+		Where("like_id = ?", likeID).
+		Delete(&deleted).Error
+	if err != nil || len(deleted) == 0 {
+		return nil, err
+	}
+	return &deleted[0], nil
+}
+
+func (r *likeFlagRepositoryGorm) DiscountByPost(ctx context.Context) ([]PostScore, error) {
+	var scores []PostScore
+	err := conn(ctx, r.db).
+		Model(&entity.LikeFlag{}).
+		Select("post_id, SUM(discount) AS score").
+		Group("post_id").
+		Scan(&scores).Error
+	return scores, err
+}
+
+func (r *likeFlagRepositoryGorm) DiscountByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]float64, error) {
+	discounts := make(map[uuid.UUID]float64, len(postIDs))
+	if len(postIDs) == 0 {
+		return discounts, nil
+	}
+
+	var rows []PostScore
+	err := conn(ctx, r.db).
+		Model(&entity.LikeFlag{}).
+		Select("post_id, SUM(discount) AS score").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		discounts[row.PostID] = row.Score
+	}
+	return discounts, nil
+}
+
+func (r *likeFlagRepositoryGorm) DiscountSince(ctx context.Context, postID uuid.UUID, since time.Time) (float64, error) {
+	var discount float64
+	err := conn(ctx, r.db).
+		Model(&entity.LikeFlag{}).
+		Select("COALESCE(SUM(discount), 0)").
+		Where("post_id = ? AND liked_at >= ?", postID, since).
+		Scan(&discount).Error
+	return discount, err
+}
+
+func (r *likeFlagRepositoryGorm) DiscountByBucket(ctx context.Context, since time.Time, size time.Duration) ([]BucketScore, error) {
+	var rows []struct {
+		PostID uuid.UUID
+		Bucket int64
+		Score  float64
+	}
+	seconds := int64(size.Seconds())
+	err := conn(ctx, r.db).
+		Model(&entity.LikeFlag{}).
+		Select("post_id, FLOOR(EXTRACT(EPOCH FROM liked_at) / ?)::bigint * ? AS bucket, SUM(discount) AS score", seconds, seconds).
+		Where("liked_at > ?", since).
+		Group("post_id, bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]BucketScore, len(rows))
+	for i, row := range rows {
+		scores[i] = BucketScore{PostID: row.PostID, Start: time.Unix(row.Bucket, 0), Score: row.Score}
+	}
+	return scores, nil
+}
@@ -2,17 +2,86 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"backend/internal/entity"
+	"backend/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LikeRepository interface {
 	FindByPostID(ctx context.Context, postID uuid.UUID) ([]entity.Like, error)
+	// ListByPost returns one page of the likes on a post, newest first.
+	ListByPost(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Like, pagination.Info, error)
 	Exists(ctx context.Context, postID, userID uuid.UUID) (bool, error)
-	ToggleLike(ctx context.Context, like *entity.Like) error
+	ToggleLike(ctx context.Context, like *entity.Like) (liked bool, changed bool, err error)
+	Like(ctx context.Context, like *entity.Like) (bool, error)
+	Unlike(ctx context.Context, like *entity.Like) (bool, error)
+	DecayedScores(ctx context.Context, since time.Time, halfLife time.Duration, gravity float64, limit int) ([]PostScore, error)
+	// DecayedLikes is the like half of one post's decayed score: its likes
+	// made after since, each weighted by 0.5^(like age / halfLife).
+	DecayedLikes(ctx context.Context, postID uuid.UUID, since time.Time, halfLife time.Duration) (float64, error)
+	// CountByPostIDSince counts the post's likes made after since, excluding
+	// since itself like CountByBucket, so the two agree on a boundary.
+	CountByPostIDSince(ctx context.Context, postID uuid.UUID, since time.Time) (int64, error)
+	// RecentLikers lists who liked the post after since. Like CoLikers it
+	// reads Postgres only, so with buffered writes it misses likes that have
+	// not been flushed yet.
+	RecentLikers(ctx context.Context, postID uuid.UUID, since time.Time) ([]Liker, error)
+	// CoLikers counts the candidates who liked at least minShared of the
+	// same posts as userID other than postID, both likes made after since.
+	CoLikers(ctx context.Context, userID, postID uuid.UUID, candidates []uuid.UUID, since time.Time, minShared int) (int64, error)
+	CountByPost(ctx context.Context) ([]PostCount, error)
+	CountByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	// LikedByUser reports which of the posts userID has liked.
+	LikedByUser(ctx context.Context, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	CountByBucket(ctx context.Context, since time.Time, size time.Duration) ([]BucketCount, error)
+	// KarmaByAuthor sums the likes on each author's posts, net of the
+	// discounts of flagged likes.
+	KarmaByAuthor(ctx context.Context) ([]AuthorScore, error)
+	// KarmaByBucket is KarmaByAuthor for likes made after since, per author
+	// and time bucket of the given size.
+	KarmaByBucket(ctx context.Context, since time.Time, size time.Duration) ([]AuthorBucketScore, error)
+	ApplyBatch(ctx context.Context, ops []LikeOp) error
+}
+
+// LikeOp is a like or unlike that has been accepted but not yet written.
+type LikeOp struct {
+	Liked bool
+	Like  entity.Like
+}
+
+// Liker is a user who liked a post, with the time their account was made.
+type Liker struct {
+	UserID        uuid.UUID
+	LikedAt       time.Time
+	UserCreatedAt time.Time
+}
+
+type PostCount struct {
+	PostID uuid.UUID
+	Count  int64
+}
+
+// BucketCount is the number of likes or comments a post received in the time
+// bucket starting at Start.
+type BucketCount struct {
+	PostID uuid.UUID
+	Start  time.Time
+	Count  int64
+}
+
+type AuthorScore struct {
+	AuthorID uuid.UUID
+	Score    float64
+}
+
+type AuthorBucketScore struct {
+	AuthorID uuid.UUID
+	Start    time.Time
+	Score    float64
 }
 
 type likeRepositoryGorm struct {
@@ -25,37 +94,383 @@ func NewLikeRepositoryGorm(db *gorm.DB) LikeRepository {
 
 func (r *likeRepositoryGorm) FindByPostID(ctx context.Context, postID uuid.UUID) ([]entity.Like, error) {
 	var likes []entity.Like
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("post_id = ?", postID).
 		Find(&likes).Error
 	return likes, err
 }
 
+func (r *likeRepositoryGorm) ListByPost(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Like, pagination.Info, error) {
+	var likes []entity.Like
+	err := keyset(conn(ctx, r.db), "likes", page).Where("post_id = ?", postID).Find(&likes).Error
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+	likes, info := pagination.Page(likes, page, likeKey)
+	return likes, info, nil
+}
+
+func likeKey(l entity.Like) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: l.CreatedAt, ID: l.ID}
+}
+
 func (r *likeRepositoryGorm) Exists(ctx context.Context, postID, userID uuid.UUID) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&entity.Like{}).
 		Where("post_id = ? AND user_id = ?", postID, userID).
 		Count(&count).Error
 	return count > 0, err
 }
 
-func (r *likeRepositoryGorm) ToggleLike(ctx context.Context, like *entity.Like) error {
-	var existing entity.Like
-	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND post_id = ?", like.UserID, like.PostID). // ✅ fixed
-		First(&existing).Error
-
-	if err == nil {
-		return r.db.WithContext(ctx).Delete(&existing).Error
+// ToggleLike removes the user's like if it exists, otherwise creates it.
+// It reports whether the post ends up liked and whether this call changed
+// anything, which it does not when a concurrent like wins the insert. On
+// removal, like is filled in with the deleted row.
+func (r *likeRepositoryGorm) ToggleLike(ctx context.Context, like *entity.Like) (bool, bool, error) {
+	removed, err := r.Unlike(ctx, like)
+	if err != nil || removed {
+		return false, removed, err
 	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return err
+	created, err := r.Like(ctx, like)
+	if err != nil {
+		return false, false, err
 	}
+	return true, created, nil
+}
 
+// Like records the user's like unless it already exists. It reports whether
+// a row was created.
+func (r *likeRepositoryGorm) Like(ctx context.Context, like *entity.Like) (bool, error) {
 	if like.ID == uuid.Nil {
 		like.ID = uuid.New()
 	}
 
-	return r.db.WithContext(ctx).Create(like).Error
+	created := false
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		res := tx.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+				DoNothing: true,
+			}).
+			Create(like)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		created = true
+		return addLikeCount(tx, like.PostID, 1)
+	})
+	return created, err
+}
+
+// Unlike removes the user's like if there is one. It reports whether a row
+// was deleted and fills like in with it.
+func (r *likeRepositoryGorm) Unlike(ctx context.Context, like *entity.Like) (bool, error) {
+	var deleted []entity.Like
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Returning{}).
+			Where("user_id = ? AND post_id = ?", like.UserID, like.PostID).
+			Delete(&deleted).Error
+		if err != nil || len(deleted) == 0 {
+			return err
+		}
+		return addLikeCount(tx, like.PostID, -1)
+	})
+	if err != nil || len(deleted) == 0 {
+		return false, err
+	}
+	*like = deleted[0]
+	return true, nil
+}
+
+// DecayedScores ranks posts by the sum of their likes made after since, each
+// weighted by 0.5^(like age / halfLife) and by the share of the like left
+// after any fraud discount, divided by
+// (post age in hours + 2)^gravity. Likes older than ten half-lives contribute
+// under 0.1% and are skipped.
+func (r *likeRepositoryGorm) DecayedScores(ctx context.Context, since time.Time, halfLife time.Duration, gravity float64, limit int) ([]PostScore, error) {
+	if horizon := time.Now().Add(-10 * halfLife); since.Before(horizon) {
+		since = horizon
+	}
+
+	var rows []struct {
+		PostID uuid.UUID
+		Score  float64
+	}
+	err := conn(ctx, r.db).
+		Table("likes").
+		Select(`likes.post_id AS post_id,
+			SUM((1 - COALESCE(like_flags.discount, 0)) * POWER(0.5, EXTRACT(EPOCH FROM (NOW() - likes.created_at)) / ?))
+				/ POWER(EXTRACT(EPOCH FROM (NOW() - posts.created_at)) / 3600 + 2, ?) AS score`,
+			halfLife.Seconds(), gravity).
+		Joins("JOIN posts ON posts.id = likes.post_id").
+		Joins("LEFT JOIN like_flags ON like_flags.like_id = likes.id").
+		Where("likes.created_at > ?", since).
+		Group("likes.post_id, posts.created_at").
+		Order("score DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]PostScore, len(rows))
+	for i, row := range rows {
+		scores[i] = PostScore{PostID: row.PostID, Score: row.Score}
+	}
+	return scores, nil
+}
+
+func (r *likeRepositoryGorm) DecayedLikes(ctx context.Context, postID uuid.UUID, since time.Time, halfLife time.Duration) (float64, error) {
+	if horizon := time.Now().Add(-10 * halfLife); since.Before(horizon) {
+		since = horizon
+	}
+
+	var score float64
+	err := conn(ctx, r.db).
+		Table("likes").
+		Select(`COALESCE(SUM((1 - COALESCE(like_flags.discount, 0))
+			* POWER(0.5, EXTRACT(EPOCH FROM (NOW() - likes.created_at)) / ?)), 0)`, halfLife.Seconds()).
+		Joins("LEFT JOIN like_flags ON like_flags.like_id = likes.id").
+		Where("likes.post_id = ? AND likes.created_at > ?", postID, since).
+		Scan(&score).Error
+	return score, err
+}
+
+func (r *likeRepositoryGorm) RecentLikers(ctx context.Context, postID uuid.UUID, since time.Time) ([]Liker, error) {
+	var likers []Liker
+	err := conn(ctx, r.db).
+		Table("likes").
+		Select("likes.user_id AS user_id, likes.created_at AS liked_at, users.created_at AS user_created_at").
+		Joins("JOIN users ON users.id = likes.user_id").
+		Where("likes.post_id = ? AND likes.created_at > ?", postID, since).
+		Scan(&likers).Error
+	return likers, err
+}
+
+func (r *likeRepositoryGorm) CoLikers(ctx context.Context, userID, postID uuid.UUID, candidates []uuid.UUID, since time.Time, minShared int) (int64, error) {
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	db := conn(ctx, r.db)
+	partners := db.
+		Table("likes AS mine").
+		Select("other.user_id").
+		Joins("JOIN likes AS other ON other.post_id = mine.post_id").
+		Where("mine.user_id = ? AND mine.post_id <> ? AND other.user_id IN ?", userID, postID, candidates).
+		Where("mine.created_at > ? AND other.created_at > ?", since, since).
+		Group("other.user_id").
+		Having("COUNT(*) >= ?", minShared)
+
+	var count int64
+	err := db.Table("(?) AS partners", partners).Count(&count).Error
+	return count, err
+}
+
+func (r *likeRepositoryGorm) CountByPostIDSince(ctx context.Context, postID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.Like{}).
+		Where("post_id = ? AND created_at > ?", postID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// CountByPost and CountByPostIDs count the like rows rather than read the
+// posts' like_count, since they are what reconciliation checks it against.
+func (r *likeRepositoryGorm) CountByPost(ctx context.Context) ([]PostCount, error) {
+	return countByPost(conn(ctx, r.db).Model(&entity.Like{}))
+}
+
+// countByPost counts the rows of a table with a post_id column per post.
+func countByPost(db *gorm.DB) ([]PostCount, error) {
+	var counts []PostCount
+	err := db.
+		Select("post_id, COUNT(*) AS count").
+		Group("post_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *likeRepositoryGorm) CountByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := countByPost(conn(ctx, r.db).Model(&entity.Like{}).Where("post_id IN ?", postIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *likeRepositoryGorm) LikedByUser(ctx context.Context, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	liked := make(map[uuid.UUID]bool, len(postIDs))
+	if len(postIDs) == 0 {
+		return liked, nil
+	}
+
+	var ids []uuid.UUID
+	err := conn(ctx, r.db).
+		Model(&entity.Like{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
+
+func (r *likeRepositoryGorm) CountByBucket(ctx context.Context, since time.Time, size time.Duration) ([]BucketCount, error) {
+	return countByBucket(conn(ctx, r.db).Model(&entity.Like{}), since, size)
+}
+
+func (r *likeRepositoryGorm) KarmaByAuthor(ctx context.Context) ([]AuthorScore, error) {
+	var scores []AuthorScore
+	err := conn(ctx, r.db).
+		Table("likes").
+		Select("posts.author_id AS author_id, SUM(1 - COALESCE(like_flags.discount, 0)) AS score").
+		Joins("JOIN posts ON posts.id = likes.post_id").
+		Joins("LEFT JOIN like_flags ON like_flags.like_id = likes.id").
+		Group("posts.author_id").
+		Scan(&scores).Error
+	return scores, err
+}
+
+func (r *likeRepositoryGorm) KarmaByBucket(ctx context.Context, since time.Time, size time.Duration) ([]AuthorBucketScore, error) {
+	var rows []struct {
+		AuthorID uuid.UUID
+		Bucket   int64
+		Score    float64
+	}
+	seconds := int64(size.Seconds())
+	err := conn(ctx, r.db).
+		Table("likes").
+		Select(`posts.author_id AS author_id,
+			FLOOR(EXTRACT(EPOCH FROM likes.created_at) / ?)::bigint * ? AS bucket,
+			SUM(1 - COALESCE(like_flags.discount, 0)) AS score`, seconds, seconds).
+		Joins("JOIN posts ON posts.id = likes.post_id").
+		Joins("LEFT JOIN like_flags ON like_flags.like_id = likes.id").
+		Where("likes.created_at > ?", since).
+		Group("posts.author_id, bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]AuthorBucketScore, len(rows))
+	for i, row := range rows {
+		scores[i] = AuthorBucketScore{AuthorID: row.AuthorID, Start: time.Unix(row.Bucket, 0), Score: row.Score}
+	}
+	return scores, nil
+}
+
+// countByBucket counts the rows of a table with post_id and created_at
+// columns per post and time bucket.
+func countByBucket(db *gorm.DB, since time.Time, size time.Duration) ([]BucketCount, error) {
+	var rows []struct {
+		PostID uuid.UUID
+		Bucket int64
+		Count  int64
+	}
+	seconds := int64(size.Seconds())
+	err := db.
+		Select("post_id, FLOOR(EXTRACT(EPOCH FROM created_at) / ?)::bigint * ? AS bucket, COUNT(*) AS count", seconds, seconds).
+		Where("created_at > ?", since).
+		Group("post_id, bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]BucketCount, len(rows))
+	for i, row := range rows {
+		counts[i] = BucketCount{PostID: row.PostID, Start: time.Unix(row.Bucket, 0), Count: row.Count}
+	}
+	return counts, nil
+}
+
+// ApplyBatch writes a run of buffered toggles in one transaction. Only the
+// last op for each (user, post) pair matters, so every touched pair is
+// cleared and the surviving likes are re-inserted with their original IDs.
+// Applying the same batch twice leaves the table unchanged. Ops on posts
+// deleted since they were buffered are dropped.
+func (r *likeRepositoryGorm) ApplyBatch(ctx context.Context, ops []LikeOp) error {
+	type pair struct{ userID, postID uuid.UUID }
+	final := make(map[pair]LikeOp, len(ops))
+	for _, op := range ops {
+		final[pair{op.Like.UserID, op.Like.PostID}] = op
+	}
+	if len(final) == 0 {
+		return nil
+	}
+
+	postIDs := make([]uuid.UUID, 0, len(final))
+	seen := make(map[uuid.UUID]bool, len(final))
+	for p := range final {
+		if !seen[p.postID] {
+			seen[p.postID] = true
+			postIDs = append(postIDs, p.postID)
+		}
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Which toggles of a replayed batch change anything is not known
+		// up front, so the counters of the touched posts are recounted
+		// instead of adjusted, and their authors' karma moved to match.
+		// Locking the posts first keeps a concurrent write from landing
+		// between the count and the update.
+		existing, err := lockPosts(tx, postIDs)
+		if err != nil || len(existing) == 0 {
+			return err
+		}
+
+		// A deleted post took its likes with it, but not those still in
+		// the buffer. Inserting them would fail the foreign key on every
+		// flush and hold up every toggle behind them.
+		exists := make(map[uuid.UUID]bool, len(existing))
+		for _, id := range existing {
+			exists[id] = true
+		}
+		pairs := make([][]interface{}, 0, len(final))
+		likes := make([]entity.Like, 0, len(final))
+		for p, op := range final {
+			if !exists[p.postID] {
+				continue
+			}
+			pairs = append(pairs, []interface{}{p.userID, p.postID})
+			if op.Liked {
+				likes = append(likes, op.Like)
+			}
+		}
+
+		if err := tx.Where("(user_id, post_id) IN ?", pairs).Delete(&entity.Like{}).Error; err != nil {
+			return err
+		}
+		if len(likes) > 0 {
+			// A concurrent writer may have re-inserted a pair in the
+			// meantime; it holds the same state, so keep its row.
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+				DoNothing: true,
+			}).CreateInBatches(likes, 500).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := addRecountKarma(tx, existing); err != nil {
+			return err
+		}
+		_, err = recountPosts(tx.Where("id IN ?", existing), "like_count", likeCountQuery)
+		return err
+	})
 }
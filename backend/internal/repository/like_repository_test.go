@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingDB is a database/sql driver that records every statement and
+// knows only which posts exist: locking posts returns those, and every other
+// query returns no rows.
+type recordingDB struct {
+	posts      map[string]bool
+	statements []recordedStatement
+}
+
+type recordedStatement struct {
+	query string
+	args  []driver.NamedValue
+}
+
+func (d *recordingDB) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d *recordingDB) Driver() driver.Driver                        { return d }
+func (d *recordingDB) Open(string) (driver.Conn, error)             { return d, nil }
+func (d *recordingDB) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported")
+}
+func (d *recordingDB) Close() error              { return nil }
+func (d *recordingDB) Begin() (driver.Tx, error) { return d, nil }
+func (d *recordingDB) Commit() error             { return nil }
+func (d *recordingDB) Rollback() error           { return nil }
+
+func (d *recordingDB) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	d.statements = append(d.statements, recordedStatement{query, args})
+	return driver.RowsAffected(0), nil
+}
+
+func (d *recordingDB) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d.statements = append(d.statements, recordedStatement{query, args})
+	rows := &recordedRows{columns: []string{"id"}}
+	if strings.Contains(query, `FROM "posts"`) && strings.HasSuffix(query, "FOR UPDATE") {
+		for _, arg := range args {
+			if id := fmt.Sprint(arg.Value); d.posts[id] {
+				rows.values = append(rows.values, id)
+			}
+		}
+	}
+	return rows, nil
+}
+
+type recordedRows struct {
+	columns []string
+	values  []string
+}
+
+func (r *recordedRows) Columns() []string { return r.columns }
+func (r *recordedRows) Close() error      { return nil }
+func (r *recordedRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func openRecordingDB(t *testing.T, posts ...uuid.UUID) (*gorm.DB, *recordingDB) {
+	t.Helper()
+	rec := &recordingDB{posts: make(map[string]bool)}
+	for _, id := range posts {
+		rec.posts[id.String()] = true
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(rec)}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	return db, rec
+}
+
+func TestApplyBatchDropsDeletedPosts(t *testing.T) {
+	kept, deleted := uuid.New(), uuid.New()
+	like := func(postID uuid.UUID) LikeOp {
+		return LikeOp{Liked: true, Like: entity.Like{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			PostID:    postID,
+			CreatedAt: time.Now(),
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		ops        []LikeOp
+		wantInsert bool
+	}{
+		{name: "only deleted", ops: []LikeOp{like(deleted)}, wantInsert: false},
+		{name: "mixed", ops: []LikeOp{like(deleted), like(kept)}, wantInsert: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, rec := openRecordingDB(t, kept)
+			if err := NewLikeRepositoryGorm(db).ApplyBatch(context.Background(), tt.ops); err != nil {
+				t.Fatalf("ApplyBatch: %v", err)
+			}
+
+			inserted := false
+			for _, s := range rec.statements {
+				if strings.HasSuffix(s.query, "FOR UPDATE") {
+					continue
+				}
+				inserted = inserted || strings.HasPrefix(s.query, `INSERT INTO "likes"`)
+				for _, arg := range s.args {
+					if fmt.Sprint(arg.Value) == deleted.String() {
+						t.Errorf("deleted post written by %s", s.query)
+					}
+				}
+			}
+			if inserted != tt.wantInsert {
+				t.Errorf("inserted likes = %v, want %v", inserted, tt.wantInsert)
+			}
+		})
+	}
+}
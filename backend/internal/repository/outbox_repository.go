@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, entry *entity.OutboxEntry) error
+	// ClaimDue locks up to limit undelivered entries that are due and have
+	// been tried fewer than maxAttempts times, oldest first. Entries locked
+	// by another relay are skipped. It must run inside a transaction, which
+	// holds the locks until the entries are marked.
+	ClaimDue(ctx context.Context, limit, maxAttempts int) ([]entity.OutboxEntry, error)
+	MarkDelivered(ctx context.Context, ids []uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, reason string) error
+	// PurgeDelivered deletes entries delivered before the given time.
+	PurgeDelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+type outboxRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepositoryGorm(db *gorm.DB) OutboxRepository {
+	return &outboxRepositoryGorm{db: db}
+}
+
+func (r *outboxRepositoryGorm) Add(ctx context.Context, entry *entity.OutboxEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.NextAttemptAt.IsZero() {
+		entry.NextAttemptAt = time.Now()
+	}
+	return conn(ctx, r.db).Create(entry).Error
+}
+
+func (r *outboxRepositoryGorm) ClaimDue(ctx context.Context, limit, maxAttempts int) ([]entity.OutboxEntry, error) {
+	var entries []entity.OutboxEntry
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("delivered_at IS NULL AND next_attempt_at <= ? AND attempts < ?", time.Now(), maxAttempts).
+		Order("created_at").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *outboxRepositoryGorm) MarkDelivered(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Model(&entity.OutboxEntry{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"delivered_at": time.Now(),
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error
+}
+
+func (r *outboxRepositoryGorm) MarkFailed(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, reason string) error {
+	return conn(ctx, r.db).
+		Model(&entity.OutboxEntry{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      reason,
+		}).Error
+}
+
+func (r *outboxRepositoryGorm) PurgeDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("delivered_at < ?", before).
+		Delete(&entity.OutboxEntry{})
+	return result.RowsAffected, result.Error
+}
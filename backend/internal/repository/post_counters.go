@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"backend/internal/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The rows behind each denormalized post counter.
+const (
+	likeCountQuery    = "SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id"
+	commentCountQuery = "SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id"
+)
+
+// addPostCount moves a counter column of a post by delta. Called in the
+// transaction that inserts or deletes the counted row.
+func addPostCount(db *gorm.DB, postID uuid.UUID, column string, delta int64) error {
+	return db.
+		Model(&entity.Post{}).
+		Where("id = ?", postID).
+		UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
+}
+
+// addLikeCount moves a post's like_count by delta, and its author's karma
+// with it. Called in the transaction that inserts or deletes the like.
+func addLikeCount(db *gorm.DB, postID uuid.UUID, delta int64) error {
+	if err := addPostCount(db, postID, "like_count", delta); err != nil {
+		return err
+	}
+	return db.
+		Model(&entity.User{}).
+		Where("id = (SELECT author_id FROM posts WHERE id = ?)", postID).
+		UpdateColumn("karma", gorm.Expr("karma + ?", delta)).Error
+}
+
+// addRecountKarma moves the karma of the posts' authors by how far the posts'
+// like_count is off their like rows. It must run just before like_count is
+// recounted, so karma follows the counters.
+func addRecountKarma(db *gorm.DB, postIDs []uuid.UUID) error {
+	return db.Exec(`UPDATE users SET karma = karma + off.delta
+		FROM (SELECT author_id, SUM((`+likeCountQuery+`) - like_count) AS delta
+			FROM posts WHERE id IN ? GROUP BY author_id) AS off
+		WHERE users.id = off.author_id AND off.delta <> 0`, postIDs).Error
+}
+
+// postCounts reads a counter column of every post it is not zero for.
+func postCounts(db *gorm.DB, column string) ([]PostCount, error) {
+	var counts []PostCount
+	err := db.
+		Model(&entity.Post{}).
+		Select("id AS post_id, " + column + " AS count").
+		Where(column + " > 0").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func postCountsByID(db *gorm.DB, column string, postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := postCounts(db.Where("id IN ?", postIDs), column)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+// lockPosts takes the row locks of posts in a fixed order, so transactions
+// locking overlapping sets cannot deadlock. It returns the posts that exist.
+func lockPosts(db *gorm.DB, postIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var locked []uuid.UUID
+	err := db.
+		Model(&entity.Post{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", postIDs).
+		Order("id").
+		Pluck("id", &locked).Error
+	return locked, err
+}
+
+// recountPosts sets a counter column from query on the posts db selects
+// whose counter is off, returning how many that was.
+func recountPosts(db *gorm.DB, column, query string) (int64, error) {
+	res := db.
+		Model(&entity.Post{}).
+		Where(column+" <> ("+query+")").
+		UpdateColumn(column, gorm.Expr("("+query+")"))
+	return res.RowsAffected, res.Error
+}
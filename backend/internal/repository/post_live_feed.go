@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const postLiveChannelPrefix = "posts:live:"
+
+// PostLiveMessage is an update about one post.
+type PostLiveMessage struct {
+	PostID uuid.UUID
+	Data   []byte
+}
+
+// PostLiveFeed fans per-post updates out to every instance.
+type PostLiveFeed interface {
+	Publish(ctx context.Context, postID uuid.UUID, data []byte) error
+	// Subscribe delivers updates for all posts published from now on until
+	// ctx is done.
+	Subscribe(ctx context.Context) (<-chan PostLiveMessage, error)
+}
+
+type postLiveFeedRedis struct {
+	rdb *redis.Client
+}
+
+// NewPostLiveFeedRedis publishes each post's updates on its own pub/sub
+// channel; subscribers listen on all of them with one pattern.
+func NewPostLiveFeedRedis(rdb *redis.Client) PostLiveFeed {
+	return &postLiveFeedRedis{rdb: rdb}
+}
+
+func (f *postLiveFeedRedis) Publish(ctx context.Context, postID uuid.UUID, data []byte) error {
+	return f.rdb.Publish(ctx, postLiveChannelPrefix+postID.String(), data).Err()
+}
+
+func (f *postLiveFeedRedis) Subscribe(ctx context.Context) (<-chan PostLiveMessage, error) {
+	pubsub := f.rdb.PSubscribe(ctx, postLiveChannelPrefix+"*")
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	messages := make(chan PostLiveMessage, 64)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		incoming := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-incoming:
+				if !ok {
+					return
+				}
+				postID, err := uuid.Parse(strings.TrimPrefix(msg.Channel, postLiveChannelPrefix))
+				if err != nil {
+					continue
+				}
+				select {
+				case messages <- PostLiveMessage{PostID: postID, Data: []byte(msg.Payload)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
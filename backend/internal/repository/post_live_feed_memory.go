@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// postLiveFeedMemory is a PostLiveFeed for a single instance.
+type postLiveFeedMemory struct {
+	mu          sync.Mutex
+	subscribers map[chan PostLiveMessage]struct{}
+}
+
+func NewPostLiveFeedMemory() PostLiveFeed {
+	return &postLiveFeedMemory{subscribers: make(map[chan PostLiveMessage]struct{})}
+}
+
+func (f *postLiveFeedMemory) Publish(ctx context.Context, postID uuid.UUID, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- PostLiveMessage{PostID: postID, Data: data}:
+		default:
+			// Like Redis pub/sub, a subscriber that cannot keep up misses
+			// updates rather than stalling the publisher.
+		}
+	}
+	return nil
+}
+
+func (f *postLiveFeedMemory) Subscribe(ctx context.Context) (<-chan PostLiveMessage, error) {
+	messages := make(chan PostLiveMessage, 64)
+	f.mu.Lock()
+	f.subscribers[messages] = struct{}{}
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		delete(f.subscribers, messages)
+		close(messages)
+		f.mu.Unlock()
+	}()
+	return messages, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/entity"
+	"backend/pkg/pagination"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostSort string
+
+const (
+	PostSortNew           PostSort = "new"
+	PostSortOld           PostSort = "old"
+	PostSortTop           PostSort = "top"
+	PostSortTrending      PostSort = "trending"
+	PostSortMostCommented PostSort = "most_commented"
+)
+
+// PostQuery filters and orders a list of posts. Zero fields do not filter,
+// and an empty Sort lists the newest first.
+type PostQuery struct {
+	AuthorID uuid.UUID
+	// Since and Until bound the creation time, Since inclusive.
+	Since    time.Time
+	Until    time.Time
+	HasImage *bool
+	Tag      string
+	// Text is matched case-insensitively anywhere in the title or content.
+	Text string
+	Sort PostSort
+	// Gravity is how fast age sinks posts under PostSortTrending.
+	Gravity float64
+}
+
+// scopes returns one gorm scope per filter set, to be applied together.
+func (q PostQuery) scopes() []func(*gorm.DB) *gorm.DB {
+	var scopes []func(*gorm.DB) *gorm.DB
+	where := func(query string, args ...interface{}) {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where(query, args...) })
+	}
+
+	if q.AuthorID != uuid.Nil {
+		where("posts.author_id = ?", q.AuthorID)
+	}
+	if !q.Since.IsZero() {
+		where("posts.created_at >= ?", q.Since)
+	}
+	if !q.Until.IsZero() {
+		where("posts.created_at < ?", q.Until)
+	}
+	if q.HasImage != nil {
+		if *q.HasImage {
+			where("COALESCE(posts.image_url, '') <> ''")
+		} else {
+			where("COALESCE(posts.image_url, '') = ''")
+		}
+	}
+	if q.Tag != "" {
+		where(`EXISTS (SELECT 1 FROM post_tags
+			JOIN tags ON tags.id = post_tags.tag_id
+			WHERE post_tags.post_id = posts.id AND tags.name = ?)`, q.Tag)
+	}
+	if q.Text != "" {
+		pattern := "%" + likeEscaper.Replace(q.Text) + "%"
+		where("(posts.title ILIKE ? OR posts.content ILIKE ?)", pattern, pattern)
+	}
+	return scopes
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// order returns what leads (created_at, id) in q's order, taken as of at,
+// and whether the order runs oldest first.
+func (q PostQuery) order(at time.Time) (*leadKey, bool, error) {
+	count := func(column string) *leadKey {
+		return &leadKey{
+			row: clause.Expr{SQL: "posts." + column},
+			cursor: func(c *pagination.Cursor) clause.Expr {
+				return clause.Expr{SQL: "?", Vars: []interface{}{c.Value}}
+			},
+		}
+	}
+
+	switch q.sort() {
+	case PostSortNew:
+		return nil, false, nil
+	case PostSortOld:
+		return nil, true, nil
+	case PostSortTop:
+		return count("like_count"), false, nil
+	case PostSortMostCommented:
+		return count("comment_count"), false, nil
+	case PostSortTrending:
+		// The cursor's score is computed from its likes and creation time
+		// by the same expression as the rows', so the two compare exactly.
+		return &leadKey{
+			row: clause.Expr{
+				SQL:  trendingScore("posts.like_count", "posts.created_at"),
+				Vars: []interface{}{at, q.Gravity},
+			},
+			cursor: func(c *pagination.Cursor) clause.Expr {
+				return clause.Expr{
+					SQL:  trendingScore("?::bigint", "?::timestamptz"),
+					Vars: []interface{}{c.Value, at, c.CreatedAt, q.Gravity},
+				}
+			},
+		}, false, nil
+	default:
+		return nil, false, fmt.Errorf("unknown post sort %q", q.Sort)
+	}
+}
+
+// trendingScore is likes / (age in hours + 2)^gravity, the decay trending
+// algorithm's weighting with every like counted at full weight, for a post
+// with the given likes and creation time. Its placeholders are the time the
+// age is taken at and the gravity. Posts created after that time count as
+// new.
+func trendingScore(likes, createdAt string) string {
+	return fmt.Sprintf(
+		"(%s / POWER(GREATEST(EXTRACT(EPOCH FROM (?::timestamptz - %s)), 0) / 3600 + 2, ?))",
+		likes, createdAt,
+	)
+}
+
+func (q PostQuery) sort() PostSort {
+	if q.Sort == "" {
+		return PostSortNew
+	}
+	return q.Sort
+}
+
+// key returns the cursor of a post in q's order as of at.
+func (q PostQuery) key(at time.Time) pagination.Key[entity.Post] {
+	sort := q.sort()
+	return func(p entity.Post) pagination.Cursor {
+		c := pagination.Cursor{Sort: string(sort), CreatedAt: p.CreatedAt, ID: p.ID}
+		switch sort {
+		case PostSortTop:
+			c.Value = p.LikeCount
+		case PostSortMostCommented:
+			c.Value = p.CommentCount
+		case PostSortTrending:
+			c.Value = p.LikeCount
+			c.At = at
+		}
+		return c
+	}
+}
@@ -1,17 +1,34 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"backend/internal/entity"
+	"backend/pkg/pagination"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepository interface {
-	Create(post *entity.Post) error
-	GetByID(id uuid.UUID) (*entity.Post, error)
-	GetAll() ([]entity.Post, error)
-	Update(post *entity.Post) error
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, post *entity.Post) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.Post, error)
+	// List returns one page of the posts query selects, in its order. A
+	// cursor from a list in another order is pagination.ErrInvalidCursor.
+	List(ctx context.Context, query PostQuery, page pagination.Params) ([]entity.Post, pagination.Info, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]entity.Post, error)
+	GetAuthorID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	SampleIDs(ctx context.Context, n int) ([]uuid.UUID, error)
+	CreationTimes(ctx context.Context) (map[uuid.UUID]time.Time, error)
+	Update(ctx context.Context, post *entity.Post) error
+	// Delete removes the post and takes the likes it received off its
+	// author's karma, which only counts likes on posts that exist.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// RecountCounters sets the like and comment counters of every post from
+	// the likes and comments tables, returning how many of each were off.
+	RecountCounters(ctx context.Context) (likes, comments int64, err error)
 }
 
 type PostRepositoryGorm struct {
@@ -22,35 +39,148 @@ func NewPostRepositoryGorm(db *gorm.DB) *PostRepositoryGorm {
 	return &PostRepositoryGorm{db: db}
 }
 
-func (r *PostRepositoryGorm) Create(post *entity.Post) error {
+func (r *PostRepositoryGorm) Create(ctx context.Context, post *entity.Post) error {
 	if post.ID == uuid.Nil {
 		post.ID = uuid.New()
 	}
-	return r.db.Create(post).Error
+	return conn(ctx, r.db).Create(post).Error
 }
 
-func (r *PostRepositoryGorm) GetByID(id uuid.UUID) (*entity.Post, error) {
+func (r *PostRepositoryGorm) GetByID(ctx context.Context, id uuid.UUID) (*entity.Post, error) {
 	var post entity.Post
-	err := r.db.
-		Preload("Likes").
-		Preload("Comments").
+	err := conn(ctx, r.db).
+		Preload("Tags").
 		First(&post, "id = ?", id).Error
 	return &post, err
 }
 
-func (r *PostRepositoryGorm) GetAll() ([]entity.Post, error) {
+func (r *PostRepositoryGorm) List(ctx context.Context, query PostQuery, page pagination.Params) ([]entity.Post, pagination.Info, error) {
+	// Orders that change with time are taken as of the first page's time
+	// on every page.
+	at := time.Now().Truncate(time.Microsecond)
+	if c := page.Cursor; c != nil {
+		if c.Sort != string(query.sort()) || (query.sort() == PostSortTrending && c.At.IsZero()) {
+			return nil, pagination.Info{}, pagination.ErrInvalidCursor
+		}
+		at = c.At
+	}
+	lead, asc, err := query.order(at)
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+
 	var posts []entity.Post
-	err := r.db.
-		Preload("Likes").
-		Preload("Comments").
+	err = keysetBy(conn(ctx, r.db), "posts", page, lead, asc).
+		Scopes(query.scopes()...).
+		Preload("Tags").
+		Find(&posts).Error
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+	posts, info := pagination.Page(posts, page, query.key(at))
+	return posts, info, nil
+}
+
+func (r *PostRepositoryGorm) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]entity.Post, error) {
+	var posts []entity.Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	err := conn(ctx, r.db).
+		Preload("Tags").
+		Where("id IN ?", ids).
 		Find(&posts).Error
 	return posts, err
 }
 
-func (r *PostRepositoryGorm) Update(post *entity.Post) error {
-	return r.db.Save(post).Error
+func (r *PostRepositoryGorm) GetAuthorID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	var post entity.Post
+	if err := conn(ctx, r.db).Select("author_id").First(&post, "id = ?", id).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return post.AuthorID, nil
+}
+
+func (r *PostRepositoryGorm) SampleIDs(ctx context.Context, n int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := conn(ctx, r.db).
+		Model(&entity.Post{}).
+		Order("RANDOM()").
+		Limit(n).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *PostRepositoryGorm) CreationTimes(ctx context.Context) (map[uuid.UUID]time.Time, error) {
+	var rows []struct {
+		ID        uuid.UUID
+		CreatedAt time.Time
+	}
+	err := conn(ctx, r.db).
+		Model(&entity.Post{}).
+		Select("id, created_at").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[uuid.UUID]time.Time, len(rows))
+	for _, row := range rows {
+		times[row.ID] = row.CreatedAt
+	}
+	return times, nil
+}
+
+// Update leaves the post's tags and counters alone. Tags are replaced through
+// TagRepository.SetPostTags, and post may have been read before likes or
+// comments that came in since.
+func (r *PostRepositoryGorm) Update(ctx context.Context, post *entity.Post) error {
+	return conn(ctx, r.db).Omit("Tags", "LikeCount", "CommentCount").Save(post).Error
+}
+
+func (r *PostRepositoryGorm) Delete(ctx context.Context, id uuid.UUID) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Locking the post keeps a like or comment from landing between
+		// deleting the post's rows and the post itself.
+		var post entity.Post
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("author_id").
+			Take(&post, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Likes and comments reference the post without cascading.
+		if err := tx.Where("post_id = ?", id).Delete(&entity.Comment{}).Error; err != nil {
+			return err
+		}
+		likes := tx.Where("post_id = ?", id).Delete(&entity.Like{})
+		if likes.Error != nil {
+			return likes.Error
+		}
+		if err := tx.Delete(&entity.Post{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if likes.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Model(&entity.User{}).
+			Where("id = ?", post.AuthorID).
+			UpdateColumn("karma", gorm.Expr("karma - ?", likes.RowsAffected)).Error
+	})
 }
 
-func (r *PostRepositoryGorm) Delete(id uuid.UUID) error {
-	return r.db.Delete(&entity.Post{}, "id = ?", id).Error
+func (r *PostRepositoryGorm) RecountCounters(ctx context.Context) (likes, comments int64, err error) {
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if likes, err = recountPosts(tx, "like_count", likeCountQuery); err != nil {
+			return err
+		}
+		comments, err = recountPosts(tx, "comment_count", commentCountQuery)
+		return err
+	})
+	return likes, comments, err
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimiter counts hits per key in fixed windows.
+type RateLimiter interface {
+	// Allow records a hit on key and reports whether it is within limit hits
+	// for the current window.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error)
+}
+
+type rateLimiterRedis struct {
+	rdb *redis.Client
+}
+
+func NewRateLimiterRedis(rdb *redis.Client) RateLimiter {
+	return &rateLimiterRedis{rdb: rdb}
+}
+
+func (l *rateLimiterRedis) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	start := time.Now().Truncate(window)
+	windowKey := fmt.Sprintf("ratelimit:%s:%d", key, start.Unix())
+
+	pipe := l.rdb.TxPipeline()
+	hits := pipe.Incr(ctx, windowKey)
+	pipe.ExpireAt(ctx, windowKey, start.Add(window))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return hits.Val() <= int64(limit), nil
+}
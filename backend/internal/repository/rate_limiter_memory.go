@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiterMemory is a RateLimiter for a single instance.
+type rateLimiterMemory struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryRateWindow
+	lastSweep time.Time
+}
+
+type memoryRateWindow struct {
+	start time.Time
+	end   time.Time
+	hits  int
+}
+
+func NewRateLimiterMemory() RateLimiter {
+	return &rateLimiterMemory{windows: make(map[string]*memoryRateWindow)}
+}
+
+func (l *rateLimiterMemory) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= time.Minute {
+		for k, w := range l.windows {
+			if !now.Before(w.end) {
+				delete(l.windows, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	start := now.Truncate(window)
+	w, ok := l.windows[key]
+	if !ok || !w.start.Equal(start) {
+		w = &memoryRateWindow{start: start, end: start.Add(window)}
+		l.windows[key] = w
+	}
+	w.hits++
+	return w.hits <= limit, nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagPostScore is what the likes of one post add to the board of one of its
+// tags.
+type TagPostScore struct {
+	Tag    string
+	PostID uuid.UUID
+	Score  float64
+}
+
+// TagBucketScore is what the likes made in the time bucket starting at Start
+// add to the score of a tag.
+type TagBucketScore struct {
+	Tag   string
+	Start time.Time
+	Score float64
+}
+
+type TagRepository interface {
+	// Upsert creates the tags that do not exist yet and returns all of them.
+	Upsert(ctx context.Context, names []string) ([]entity.Tag, error)
+	// SetPostTags replaces the tags of a post.
+	SetPostTags(ctx context.Context, post *entity.Post, tags []entity.Tag) error
+	NamesByPost(ctx context.Context, postID uuid.UUID) ([]string, error)
+	Names(ctx context.Context) ([]string, error)
+	// LikesByTagPost sums the likes of each tagged post per tag, net of the
+	// discounts of flagged likes.
+	LikesByTagPost(ctx context.Context) ([]TagPostScore, error)
+	// LikesByBucket sums the likes of tagged posts made after since per tag
+	// and time bucket of the given size, net of discounts.
+	LikesByBucket(ctx context.Context, since time.Time, size time.Duration) ([]TagBucketScore, error)
+}
+
+type tagRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewTagRepositoryGorm(db *gorm.DB) TagRepository {
+	return &tagRepositoryGorm{db: db}
+}
+
+func (r *tagRepositoryGorm) Upsert(ctx context.Context, names []string) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	db := conn(ctx, r.db)
+	rows := make([]entity.Tag, len(names))
+	for i, name := range names {
+		rows[i] = entity.Tag{ID: uuid.New(), Name: name}
+	}
+	err := db.
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	err = db.Where("name IN ?", names).Find(&tags).Error
+	return tags, err
+}
+
+func (r *tagRepositoryGorm) SetPostTags(ctx context.Context, post *entity.Post, tags []entity.Tag) error {
+	return conn(ctx, r.db).Model(post).Association("Tags").Replace(tags)
+}
+
+func (r *tagRepositoryGorm) NamesByPost(ctx context.Context, postID uuid.UUID) ([]string, error) {
+	var names []string
+	err := conn(ctx, r.db).
+		Table("tags").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Where("post_tags.post_id = ?", postID).
+		Pluck("tags.name", &names).Error
+	return names, err
+}
+
+func (r *tagRepositoryGorm) Names(ctx context.Context) ([]string, error) {
+	var names []string
+	err := conn(ctx, r.db).Model(&entity.Tag{}).Pluck("name", &names).Error
+	return names, err
+}
+
+func (r *tagRepositoryGorm) LikesByTagPost(ctx context.Context) ([]TagPostScore, error) {
+	var scores []TagPostScore
+	err := conn(ctx, r.db).
+		Table("likes").
+		Select("tags.name AS tag, likes.post_id AS post_id, SUM(1 - COALESCE(like_flags.discount, 0)) AS score").
+		Joins("JOIN post_tags ON post_tags.post_id = likes.post_id").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Joins("LEFT JOIN like_flags ON like_flags.like_id = likes.id").
+		Group("tags.name, likes.post_id").
+		Scan(&scores).Error
+	return scores, err
+}
+
+func (r *tagRepositoryGorm) LikesByBucket(ctx context.Context, since time.Time, size time.Duration) ([]TagBucketScore, error) {
+	var rows []struct {
+		Tag    string
+		Bucket int64
+		Score  float64
+	}
+	seconds := int64(size.Seconds())
+	err := conn(ctx, r.db).
+		Table("likes").
+		Select(`tags.name AS tag,
+			FLOOR(EXTRACT(EPOCH FROM likes.created_at) / ?)::bigint * ? AS bucket,
+			SUM(1 - COALESCE(like_flags.discount, 0)) AS score`, seconds, seconds).
+		Joins("JOIN post_tags ON post_tags.post_id = likes.post_id").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Joins("LEFT JOIN like_flags ON like_flags.like_id = likes.id").
+		Where("likes.created_at > ?", since).
+		Group("tags.name, bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]TagBucketScore, len(rows))
+	for i, row := range rows {
+		scores[i] = TagBucketScore{Tag: row.Tag, Start: time.Unix(row.Bucket, 0), Score: row.Score}
+	}
+	return scores, nil
+}
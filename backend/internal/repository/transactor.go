@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type rollbackKey struct{}
+
+// rollbackHooks undo steps taken outside the database, such as in Redis,
+// when the transaction they belong to rolls back.
+type rollbackHooks struct {
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+}
+
+// Transactor runs a unit of work in one database transaction. Repositories
+// called with the context passed to fn take part in the transaction.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactorGorm struct {
+	db *gorm.DB
+}
+
+func NewTransactorGorm(db *gorm.DB) Transactor {
+	return &transactorGorm{db: db}
+}
+
+// WithinTx commits if fn returns nil and rolls back otherwise, then runs
+// the hooks registered with onRollback, latest first. Called inside another
+// WithinTx it joins the outer transaction.
+func (t *transactorGorm) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	rollback := &rollbackHooks{}
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(context.WithValue(ctx, txKey{}, tx), rollbackKey{}, rollback))
+	})
+	if err != nil {
+		// The request may be what failed, so undo without its deadline.
+		undoCtx := context.WithoutCancel(ctx)
+		for i := len(rollback.hooks) - 1; i >= 0; i-- {
+			rollback.hooks[i](undoCtx)
+		}
+	}
+	return err
+}
+
+// onRollback registers undo to run if the transaction carried by ctx rolls
+// back. Outside a transaction there is nothing to roll back, so the step
+// stands and undo is dropped.
+func onRollback(ctx context.Context, undo func(ctx context.Context)) {
+	rollback, ok := ctx.Value(rollbackKey{}).(*rollbackHooks)
+	if !ok {
+		return
+	}
+	rollback.mu.Lock()
+	defer rollback.mu.Unlock()
+	rollback.hooks = append(rollback.hooks, undo)
+}
+
+// conn returns the transaction carried by ctx, or db bound to ctx when there
+// is none.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
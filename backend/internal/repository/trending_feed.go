@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	trendingFeedChannel    = "trending:feed"
+	trendingFeedHistoryKey = "trending:feed:history"
+	trendingFeedSeqKey     = "trending:feed:seq"
+	trendingFeedLockKey    = "trending:feed:lock"
+)
+
+// TrendingEvent is one published leaderboard snapshot. IDs increase with
+// every publish across all instances, so a client can resume after the last
+// one it saw.
+type TrendingEvent struct {
+	ID int64
+	// Signature identifies the ranking the snapshot holds, so an unchanged
+	// ranking is not published twice.
+	Signature string
+	Data      []byte
+}
+
+// TrendingFeed fans leaderboard snapshots out to every instance and keeps a
+// short history of them for resuming subscribers.
+type TrendingFeed interface {
+	Publish(ctx context.Context, signature string, data []byte) (TrendingEvent, error)
+	// Latest returns the most recent event, or nil if nothing was published.
+	Latest(ctx context.Context) (*TrendingEvent, error)
+	// Since returns the retained events after id, oldest first.
+	Since(ctx context.Context, id int64) ([]TrendingEvent, error)
+	// Subscribe delivers events published from now on until ctx is done.
+	Subscribe(ctx context.Context) (<-chan TrendingEvent, error)
+	// Lock claims the right to publish for ttl. It reports false while
+	// another caller holds it.
+	Lock(ctx context.Context, ttl time.Duration) (bool, error)
+}
+
+// trendingFeedEntry is how an event is stored in the history and sent over
+// pub/sub.
+type trendingFeedEntry struct {
+	ID        int64           `json:"id"`
+	Signature string          `json:"sig"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type trendingFeedRedis struct {
+	rdb     *redis.Client
+	history int
+}
+
+// NewTrendingFeedRedis publishes over Redis pub/sub and keeps the last
+// history events in a sorted set scored by ID.
+func NewTrendingFeedRedis(rdb *redis.Client, history int) TrendingFeed {
+	return &trendingFeedRedis{rdb: rdb, history: history}
+}
+
+func (f *trendingFeedRedis) Publish(ctx context.Context, signature string, data []byte) (TrendingEvent, error) {
+	id, err := f.rdb.Incr(ctx, trendingFeedSeqKey).Result()
+	if err != nil {
+		return TrendingEvent{}, err
+	}
+	event := TrendingEvent{ID: id, Signature: signature, Data: data}
+	payload, err := json.Marshal(trendingFeedEntry{ID: id, Signature: signature, Data: data})
+	if err != nil {
+		return TrendingEvent{}, err
+	}
+
+	_, err = f.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, trendingFeedHistoryKey, redis.Z{Score: float64(id), Member: payload})
+		pipe.ZRemRangeByRank(ctx, trendingFeedHistoryKey, 0, -int64(f.history)-1)
+		pipe.Publish(ctx, trendingFeedChannel, payload)
+		return nil
+	})
+	return event, err
+}
+
+func (f *trendingFeedRedis) Latest(ctx context.Context) (*TrendingEvent, error) {
+	payloads, err := f.rdb.ZRevRange(ctx, trendingFeedHistoryKey, 0, 0).Result()
+	if err != nil || len(payloads) == 0 {
+		return nil, err
+	}
+	event, err := decodeTrendingEvent(payloads[0])
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (f *trendingFeedRedis) Since(ctx context.Context, id int64) ([]TrendingEvent, error) {
+	payloads, err := f.rdb.ZRangeByScore(ctx, trendingFeedHistoryKey, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(id, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]TrendingEvent, 0, len(payloads))
+	for _, p := range payloads {
+		event, err := decodeTrendingEvent(p)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (f *trendingFeedRedis) Subscribe(ctx context.Context) (<-chan TrendingEvent, error) {
+	pubsub := f.rdb.Subscribe(ctx, trendingFeedChannel)
+	// Wait for the subscription to be confirmed so nothing published after
+	// Subscribe returns is missed.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan TrendingEvent, 16)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				event, err := decodeTrendingEvent(msg.Payload)
+				if err != nil {
+					log.Printf("⚠️ Dropping malformed trending event: %v", err)
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (f *trendingFeedRedis) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
+	return f.rdb.SetNX(ctx, trendingFeedLockKey, "1", ttl).Result()
+}
+
+func decodeTrendingEvent(payload string) (TrendingEvent, error) {
+	var entry trendingFeedEntry
+	if err := json.Unmarshal([]byte(payload), &entry); err != nil {
+		return TrendingEvent{}, err
+	}
+	if entry.ID <= 0 {
+		return TrendingEvent{}, errors.New("missing event ID")
+	}
+	return TrendingEvent{ID: entry.ID, Signature: entry.Signature, Data: entry.Data}, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// trendingFeedMemory is a TrendingFeed for a single instance. Events are
+// only seen by subscribers in the same process.
+type trendingFeedMemory struct {
+	mu          sync.Mutex
+	history     int
+	seq         int64
+	events      []TrendingEvent
+	subscribers map[chan TrendingEvent]struct{}
+	lockedUntil time.Time
+}
+
+func NewTrendingFeedMemory(history int) TrendingFeed {
+	return &trendingFeedMemory{
+		history:     history,
+		subscribers: make(map[chan TrendingEvent]struct{}),
+	}
+}
+
+func (f *trendingFeedMemory) Publish(ctx context.Context, signature string, data []byte) (TrendingEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	event := TrendingEvent{ID: f.seq, Signature: signature, Data: data}
+	f.events = append(f.events, event)
+	if len(f.events) > f.history {
+		f.events = f.events[len(f.events)-f.history:]
+	}
+	for ch := range f.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Like Redis pub/sub, a subscriber that cannot keep up misses
+			// events rather than stalling the publisher.
+		}
+	}
+	return event, nil
+}
+
+func (f *trendingFeedMemory) Latest(ctx context.Context) (*TrendingEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.events) == 0 {
+		return nil, nil
+	}
+	event := f.events[len(f.events)-1]
+	return &event, nil
+}
+
+func (f *trendingFeedMemory) Since(ctx context.Context, id int64) ([]TrendingEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var events []TrendingEvent
+	for _, e := range f.events {
+		if e.ID > id {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (f *trendingFeedMemory) Subscribe(ctx context.Context) (<-chan TrendingEvent, error) {
+	events := make(chan TrendingEvent, 16)
+	f.mu.Lock()
+	f.subscribers[events] = struct{}{}
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		delete(f.subscribers, events)
+		close(events)
+		f.mu.Unlock()
+	}()
+	return events, nil
+}
+
+func (f *trendingFeedMemory) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(f.lockedUntil) {
+		return false, nil
+	}
+	f.lockedUntil = now.Add(ttl)
+	return true, nil
+}
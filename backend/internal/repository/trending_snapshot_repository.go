@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TrendingSnapshotRepository interface {
+	// Save stores the rows of a snapshot. Rows already stored for the same
+	// window, time and rank are kept, so saving a snapshot twice is harmless.
+	Save(ctx context.Context, rows []entity.TrendingSnapshot) error
+	// FindAt returns the latest snapshot of window taken at or before at,
+	// ordered by rank, or none if there is no such snapshot.
+	FindAt(ctx context.Context, window string, at time.Time) ([]entity.TrendingSnapshot, error)
+	// PurgeBefore deletes snapshots taken before the given time.
+	PurgeBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type trendingSnapshotRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewTrendingSnapshotRepositoryGorm(db *gorm.DB) TrendingSnapshotRepository {
+	return &trendingSnapshotRepositoryGorm{db: db}
+}
+
+func (r *trendingSnapshotRepositoryGorm) Save(ctx context.Context, rows []entity.TrendingSnapshot) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	for i := range rows {
+		if rows[i].ID == uuid.Nil {
+			rows[i].ID = uuid.New()
+		}
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rows).Error
+}
+
+func (r *trendingSnapshotRepositoryGorm) FindAt(ctx context.Context, window string, at time.Time) ([]entity.TrendingSnapshot, error) {
+	latest := conn(ctx, r.db).
+		Model(&entity.TrendingSnapshot{}).
+		Select("MAX(taken_at)").
+		Where("time_window = ? AND taken_at <= ?", window, at)
+
+	var rows []entity.TrendingSnapshot
+	err := conn(ctx, r.db).
+		Where("time_window = ? AND taken_at = (?)", window, latest).
+		Order("rank").
+		Find(&rows).Error
+	return rows, err
+}
+
+func (r *trendingSnapshotRepositoryGorm) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("taken_at < ?", before).
+		Delete(&entity.TrendingSnapshot{})
+	return result.RowsAffected, result.Error
+}
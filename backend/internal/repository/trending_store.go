@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// TrendingPostsKey is the all-time post leaderboard. The Redis like toggle
+// script adjusts it directly, so it is shared with the trending usecase.
+const TrendingPostsKey = "trending:posts"
+
+type PostScore struct {
+	PostID uuid.UUID
+	Score  float64
+}
+
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// TrendingStore is a set of named leaderboards, each mapping members to
+// scores, with the operations of a Redis sorted set.
+type TrendingStore interface {
+	Set(ctx context.Context, key, member string, score float64) error
+	Incr(ctx context.Context, key, member string, delta float64) error
+	Decr(ctx context.Context, key, member string, delta float64) error
+	// TopK returns up to k members with a positive score, highest first, and
+	// none when k is not positive.
+	TopK(ctx context.Context, key string, k int) ([]ScoredMember, error)
+	// Score returns the member's score, or 0 if it is not on the board.
+	Score(ctx context.Context, key, member string) (float64, error)
+	// Rank returns the 1-based position TopK would list member at, and false
+	// if TopK would not list it at all.
+	Rank(ctx context.Context, key, member string) (int64, bool, error)
+	Remove(ctx context.Context, key, member string) error
+	Reset(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	ExpireAt(ctx context.Context, key string, at time.Time) error
+	// Union overwrites dest with the sum of the boards in keys and expires it
+	// after ttl. Each board is multiplied by the matching entry of weights
+	// first; nil weights count every board once.
+	Union(ctx context.Context, dest string, keys []string, weights []float64, ttl time.Duration) error
+}
+
+type trendingStoreRedis struct {
+	rdb *redis.Client
+}
+
+func NewTrendingStoreRedis(rdb *redis.Client) TrendingStore {
+	return &trendingStoreRedis{rdb: rdb}
+}
+
+func (s *trendingStoreRedis) Set(ctx context.Context, key, member string, score float64) error {
+	return s.rdb.ZAdd(ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+func (s *trendingStoreRedis) Incr(ctx context.Context, key, member string, delta float64) error {
+	return s.rdb.ZIncrBy(ctx, key, delta, member).Err()
+}
+
+func (s *trendingStoreRedis) Decr(ctx context.Context, key, member string, delta float64) error {
+	return s.rdb.ZIncrBy(ctx, key, -delta, member).Err()
+}
+
+func (s *trendingStoreRedis) TopK(ctx context.Context, key string, k int) ([]ScoredMember, error) {
+	// A zero Count would mean no LIMIT at all.
+	if k <= 0 {
+		return []ScoredMember{}, nil
+	}
+	// Members whose score dropped back to zero linger in the set; skip them.
+	members, err := s.rdb.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:   "(0",
+		Max:   "+inf",
+		Count: int64(k),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	scored := make([]ScoredMember, len(members))
+	for i, m := range members {
+		scored[i] = ScoredMember{Member: m.Member.(string), Score: m.Score}
+	}
+	return scored, nil
+}
+
+func (s *trendingStoreRedis) Score(ctx context.Context, key, member string) (float64, error) {
+	score, err := s.rdb.ZScore(ctx, key, member).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return score, err
+}
+
+func (s *trendingStoreRedis) Rank(ctx context.Context, key, member string) (int64, bool, error) {
+	score, err := s.Score(ctx, key, member)
+	if err != nil || score <= 0 {
+		return 0, false, err
+	}
+	rank, err := s.rdb.ZRevRank(ctx, key, member).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return rank + 1, true, nil
+}
+
+func (s *trendingStoreRedis) Remove(ctx context.Context, key, member string) error {
+	return s.rdb.ZRem(ctx, key, member).Err()
+}
+
+func (s *trendingStoreRedis) Reset(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
+
+func (s *trendingStoreRedis) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (s *trendingStoreRedis) ExpireAt(ctx context.Context, key string, at time.Time) error {
+	return s.rdb.ExpireAt(ctx, key, at).Err()
+}
+
+func (s *trendingStoreRedis) Union(ctx context.Context, dest string, keys []string, weights []float64, ttl time.Duration) error {
+	pipe := s.rdb.TxPipeline()
+	pipe.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys, Weights: weights, Aggregate: "SUM"})
+	pipe.Expire(ctx, dest, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryBoard struct {
+	scores   map[string]float64
+	expireAt time.Time
+}
+
+func (b *memoryBoard) expired(now time.Time) bool {
+	return !b.expireAt.IsZero() && !now.Before(b.expireAt)
+}
+
+// trendingStoreMemory keeps leaderboards in process memory. It suits single
+// instance deployments and tests; nothing survives a restart.
+type trendingStoreMemory struct {
+	mu        sync.RWMutex
+	boards    map[string]*memoryBoard
+	lastSweep time.Time
+}
+
+func NewTrendingStoreMemory() TrendingStore {
+	return &trendingStoreMemory{boards: make(map[string]*memoryBoard)}
+}
+
+// board returns the live board for key, dropping it if it has expired. When
+// create is set a missing board is created. Callers must hold the write lock.
+func (s *trendingStoreMemory) board(key string, create bool) *memoryBoard {
+	now := time.Now()
+	// Windowed buckets are written once and never read after their window
+	// passes, so sweep expired boards now and then rather than only on read.
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for k, b := range s.boards {
+			if b.expired(now) {
+				delete(s.boards, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.boards[key]
+	if ok && b.expired(now) {
+		delete(s.boards, key)
+		ok = false
+	}
+	if !ok && create {
+		b = &memoryBoard{scores: make(map[string]float64)}
+		s.boards[key] = b
+		ok = true
+	}
+	if !ok {
+		return nil
+	}
+	return b
+}
+
+// readBoard is board for readers holding only the read lock.
+func (s *trendingStoreMemory) readBoard(key string) *memoryBoard {
+	b, ok := s.boards[key]
+	if !ok || b.expired(time.Now()) {
+		return nil
+	}
+	return b
+}
+
+func (s *trendingStoreMemory) Set(ctx context.Context, key, member string, score float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.board(key, true).scores[member] = score
+	return nil
+}
+
+func (s *trendingStoreMemory) Incr(ctx context.Context, key, member string, delta float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.board(key, true).scores[member] += delta
+	return nil
+}
+
+func (s *trendingStoreMemory) Decr(ctx context.Context, key, member string, delta float64) error {
+	return s.Incr(ctx, key, member, -delta)
+}
+
+func (s *trendingStoreMemory) TopK(ctx context.Context, key string, k int) ([]ScoredMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b := s.readBoard(key)
+	if b == nil || k <= 0 {
+		return []ScoredMember{}, nil
+	}
+
+	scored := make([]ScoredMember, 0, len(b.scores))
+	for m, score := range b.scores {
+		if score > 0 {
+			scored = append(scored, ScoredMember{Member: m, Score: score})
+		}
+	}
+	// Match Redis: highest score first, ties broken by descending member.
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Member > scored[j].Member
+	})
+	if len(scored) > k {
+		scored = scored[:k]
+	}
+	return scored, nil
+}
+
+func (s *trendingStoreMemory) Score(ctx context.Context, key, member string) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if b := s.readBoard(key); b != nil {
+		return b.scores[member], nil
+	}
+	return 0, nil
+}
+
+func (s *trendingStoreMemory) Rank(ctx context.Context, key, member string) (int64, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b := s.readBoard(key)
+	if b == nil || b.scores[member] <= 0 {
+		return 0, false, nil
+	}
+	score := b.scores[member]
+	rank := int64(1)
+	for m, other := range b.scores {
+		// Same order as TopK.
+		if other > score || (other == score && m > member) {
+			rank++
+		}
+	}
+	return rank, true, nil
+}
+
+func (s *trendingStoreMemory) Remove(ctx context.Context, key, member string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b := s.board(key, false); b != nil {
+		delete(b.scores, member)
+	}
+	return nil
+}
+
+func (s *trendingStoreMemory) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.boards, key)
+	return nil
+}
+
+func (s *trendingStoreMemory) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.readBoard(key) != nil, nil
+}
+
+func (s *trendingStoreMemory) ExpireAt(ctx context.Context, key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b := s.board(key, false); b != nil {
+		b.expireAt = at
+	}
+	return nil
+}
+
+func (s *trendingStoreMemory) Union(ctx context.Context, dest string, keys []string, weights []float64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	union := &memoryBoard{scores: make(map[string]float64)}
+	for i, key := range keys {
+		weight := 1.0
+		if weights != nil {
+			weight = weights[i]
+		}
+		if b := s.board(key, false); b != nil {
+			for m, score := range b.scores {
+				union.scores[m] += weight * score
+			}
+		}
+	}
+	if len(union.scores) == 0 {
+		// ZUNIONSTORE of empty sets leaves no key behind.
+		delete(s.boards, dest)
+		return nil
+	}
+	union.expireAt = time.Now().Add(ttl)
+	s.boards[dest] = union
+	return nil
+}
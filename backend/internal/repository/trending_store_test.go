@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// trendingStores returns every TrendingStore backend, so the contract tests
+// run against each of them.
+func trendingStores(t *testing.T) map[string]TrendingStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return map[string]TrendingStore{
+		"memory": NewTrendingStoreMemory(),
+		"redis":  NewTrendingStoreRedis(rdb),
+	}
+}
+
+func TestTrendingStoreTopK(t *testing.T) {
+	tests := []struct {
+		name string
+		k    int
+		want []string
+	}{
+		{name: "negative", k: -1, want: nil},
+		{name: "zero", k: 0, want: nil},
+		{name: "fewer", k: 2, want: []string{"c", "b"}},
+		{name: "more", k: 10, want: []string{"c", "b", "a"}},
+	}
+	for name, store := range trendingStores(t) {
+		ctx := context.Background()
+		for member, score := range map[string]float64{"a": 1, "b": 2, "c": 2, "gone": 0} {
+			if err := store.Set(ctx, "board", member, score); err != nil {
+				t.Fatalf("%s: Set: %v", name, err)
+			}
+		}
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				got, err := store.TopK(ctx, "board", tt.k)
+				if err != nil {
+					t.Fatalf("TopK: %v", err)
+				}
+				if got == nil {
+					t.Fatalf("TopK(%d) = nil, want an empty slice", tt.k)
+				}
+				if len(got) != len(tt.want) {
+					t.Fatalf("TopK(%d) = %v, want %v", tt.k, got, tt.want)
+				}
+				for i, m := range got {
+					if m.Member != tt.want[i] {
+						t.Errorf("TopK(%d)[%d] = %s, want %s", tt.k, i, m.Member, tt.want[i])
+					}
+				}
+			})
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"backend/internal/entity"
 
 	"github.com/google/uuid"
@@ -8,9 +10,13 @@ import (
 )
 
 type UserRepository interface {
-	Create(user *entity.User) error
-	FindByID(id uuid.UUID) (*entity.User, error)
-	FindByEmail(email string) (*entity.User, error)
+	Create(ctx context.Context, user *entity.User) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	FindByEmail(ctx context.Context, email string) (*entity.User, error)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]entity.User, error)
+	// RecountKarma sets the karma of every user from the likes on their
+	// existing posts, returning how many users it was off for.
+	RecountKarma(ctx context.Context) (int64, error)
 }
 
 type userRepository struct {
@@ -21,25 +27,45 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db}
 }
 
-func (r *userRepository) Create(user *entity.User) error {
+func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
 	if user.ID == uuid.Nil {
 		user.ID = uuid.New()
 	}
-	return r.db.Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
-func (r *userRepository) FindByID(id uuid.UUID) (*entity.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
 	var user entity.User
-	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&user, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByEmail(email string) (*entity.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+func (r *userRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]entity.User, error) {
+	var users []entity.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := conn(ctx, r.db).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) RecountKarma(ctx context.Context) (int64, error) {
+	const karma = `SELECT COUNT(*) FROM likes
+		JOIN posts ON posts.id = likes.post_id
+		WHERE posts.author_id = users.id`
+	res := conn(ctx, r.db).
+		Model(&entity.User{}).
+		Where("karma <> ("+karma+")").
+		UpdateColumn("karma", gorm.Expr("("+karma+")"))
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ViewStore counts distinct viewers per key with HyperLogLog sketches, so a
+// count costs a few kilobytes however many viewers there are. Counts are
+// estimates with a standard error below 1%.
+type ViewStore interface {
+	// Add records viewer under key and reports whether the estimate grew,
+	// which means the viewer is almost certainly new to key.
+	Add(ctx context.Context, key, viewer string) (bool, error)
+	// Count estimates the distinct viewers across all of keys together.
+	Count(ctx context.Context, keys ...string) (int64, error)
+	// CountEach estimates every key on its own.
+	CountEach(ctx context.Context, keys []string) ([]int64, error)
+	ExpireAt(ctx context.Context, key string, at time.Time) error
+	Delete(ctx context.Context, key string) error
+}
+
+type viewStoreRedis struct {
+	rdb *redis.Client
+}
+
+func NewViewStoreRedis(rdb *redis.Client) ViewStore {
+	return &viewStoreRedis{rdb: rdb}
+}
+
+func (s *viewStoreRedis) Add(ctx context.Context, key, viewer string) (bool, error) {
+	changed, err := s.rdb.PFAdd(ctx, key, viewer).Result()
+	return changed == 1, err
+}
+
+func (s *viewStoreRedis) Count(ctx context.Context, keys ...string) (int64, error) {
+	return s.rdb.PFCount(ctx, keys...).Result()
+}
+
+func (s *viewStoreRedis) CountEach(ctx context.Context, keys []string) ([]int64, error) {
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.PFCount(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(keys))
+	for i, cmd := range cmds {
+		counts[i] = cmd.Val()
+	}
+	return counts, nil
+}
+
+func (s *viewStoreRedis) ExpireAt(ctx context.Context, key string, at time.Time) error {
+	return s.rdb.ExpireAt(ctx, key, at).Err()
+}
+
+func (s *viewStoreRedis) Delete(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
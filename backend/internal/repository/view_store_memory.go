@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// viewStoreMemory is a ViewStore for a single instance. It keeps every viewer
+// in a set, so its counts are exact.
+type viewStoreMemory struct {
+	mu        sync.Mutex
+	sets      map[string]*memoryViewSet
+	lastSweep time.Time
+}
+
+type memoryViewSet struct {
+	viewers  map[string]struct{}
+	expireAt time.Time
+}
+
+func NewViewStoreMemory() ViewStore {
+	return &viewStoreMemory{sets: make(map[string]*memoryViewSet)}
+}
+
+// set returns the live set stored under key, dropping it if it has expired.
+// The caller must hold s.mu.
+func (s *viewStoreMemory) set(key string) *memoryViewSet {
+	set, ok := s.sets[key]
+	if ok && set.expired(time.Now()) {
+		delete(s.sets, key)
+		return nil
+	}
+	return set
+}
+
+func (set *memoryViewSet) expired(now time.Time) bool {
+	return !set.expireAt.IsZero() && !now.Before(set.expireAt)
+}
+
+func (s *viewStoreMemory) Add(ctx context.Context, key, viewer string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Past buckets are never read again, so sweep expired sets now and
+	// then so the map does not grow without bound.
+	if now := time.Now(); now.Sub(s.lastSweep) >= time.Minute {
+		for k, set := range s.sets {
+			if set.expired(now) {
+				delete(s.sets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	set := s.set(key)
+	if set == nil {
+		set = &memoryViewSet{viewers: make(map[string]struct{})}
+		s.sets[key] = set
+	}
+	if _, ok := set.viewers[viewer]; ok {
+		return false, nil
+	}
+	set.viewers[viewer] = struct{}{}
+	return true, nil
+}
+
+func (s *viewStoreMemory) Count(ctx context.Context, keys ...string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	union := make(map[string]struct{})
+	for _, key := range keys {
+		if set := s.set(key); set != nil {
+			for viewer := range set.viewers {
+				union[viewer] = struct{}{}
+			}
+		}
+	}
+	return int64(len(union)), nil
+}
+
+func (s *viewStoreMemory) CountEach(ctx context.Context, keys []string) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make([]int64, len(keys))
+	for i, key := range keys {
+		if set := s.set(key); set != nil {
+			counts[i] = int64(len(set.viewers))
+		}
+	}
+	return counts, nil
+}
+
+func (s *viewStoreMemory) ExpireAt(ctx context.Context, key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if set := s.set(key); set != nil {
+		set.expireAt = at
+	}
+	return nil
+}
+
+func (s *viewStoreMemory) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sets, key)
+	return nil
+}
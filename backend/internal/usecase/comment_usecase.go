@@ -2,8 +2,11 @@ package usecase
 
 import (
 	"backend/internal/entity"
+	"backend/internal/event"
 	"backend/internal/repository"
+	"backend/pkg/pagination"
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,20 +15,32 @@ type CommentUsecase interface {
 	CreateComment(ctx context.Context, comment *entity.Comment) error
 	UpdateComment(ctx context.Context, comment *entity.Comment) error
 	DeleteComment(ctx context.Context, commentID uuid.UUID) error
-	GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]entity.Comment, error)
+	GetCommentsByPostID(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Comment, pagination.Info, error)
 	GetCommentByID(ctx context.Context, id uuid.UUID) (*entity.Comment, error)
 }
 
 type commentUsecase struct {
-	repo repository.CommentRepository
+	repo   repository.CommentRepository
+	tx     repository.Transactor
+	events event.Publisher
 }
 
-func NewCommentUsecase(repo repository.CommentRepository) CommentUsecase {
-	return &commentUsecase{repo: repo}
+func NewCommentUsecase(repo repository.CommentRepository, tx repository.Transactor, events event.Publisher) CommentUsecase {
+	return &commentUsecase{repo: repo, tx: tx, events: events}
 }
 
 func (uc *commentUsecase) CreateComment(ctx context.Context, comment *entity.Comment) error {
-	return uc.repo.Create(ctx, comment)
+	return uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.repo.Create(ctx, comment); err != nil {
+			return err
+		}
+		return uc.events.Publish(ctx, event.CommentCreated{
+			CommentID: comment.ID,
+			PostID:    comment.PostID,
+			UserID:    comment.UserID,
+			At:        comment.CreatedAt,
+		})
+	})
 }
 
 func (uc *commentUsecase) UpdateComment(ctx context.Context, comment *entity.Comment) error {
@@ -33,11 +48,26 @@ func (uc *commentUsecase) UpdateComment(ctx context.Context, comment *entity.Com
 }
 
 func (uc *commentUsecase) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
-	return uc.repo.Delete(ctx, commentID)
+	return uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		comment, err := uc.repo.GetCommentByID(ctx, commentID)
+		if err != nil {
+			return err
+		}
+		if err := uc.repo.Delete(ctx, commentID); err != nil {
+			return err
+		}
+		return uc.events.Publish(ctx, event.CommentDeleted{
+			CommentID: comment.ID,
+			PostID:    comment.PostID,
+			UserID:    comment.UserID,
+			CreatedAt: comment.CreatedAt,
+			At:        time.Now(),
+		})
+	})
 }
 
-func (uc *commentUsecase) GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]entity.Comment, error) {
-	return uc.repo.FindByPostID(ctx, postID)
+func (uc *commentUsecase) GetCommentsByPostID(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Comment, pagination.Info, error) {
+	return uc.repo.FindByPostID(ctx, postID, page)
 }
 
 func (uc *commentUsecase) GetCommentByID(ctx context.Context, id uuid.UUID) (*entity.Comment, error) {
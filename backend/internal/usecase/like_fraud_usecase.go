@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"expvar"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var likesFlagged = expvar.NewInt("likes_flagged_total")
+
+type LikeFraudUsecase interface {
+	// Assess checks a like that was just made and, if it looks like gaming,
+	// flags it. It returns the share of the like, between 0 and 1, to leave
+	// out of trending. The burst and co-like checks see other likes once
+	// they reach Postgres, so with buffered writes they lag by up to one
+	// flush interval and a burst inside it is undercounted.
+	Assess(ctx context.Context, like *entity.Like) (float64, error)
+	// Release drops the flag of a like that was removed and returns the
+	// discount it carried.
+	Release(ctx context.Context, like *entity.Like) (float64, error)
+}
+
+// LikeFraudOptions holds the thresholds of each check. A discount of 0
+// turns its check off.
+type LikeFraudOptions struct {
+	// Likes from accounts younger than NewAccountAge are discounted by
+	// NewAccountDiscount.
+	NewAccountAge      time.Duration
+	NewAccountDiscount float64
+	// A new account's like is further discounted by BurstDiscount when the
+	// post got at least BurstMinLikes likes in the last BurstWindow and at
+	// least BurstNewRatio of them came from new accounts.
+	BurstWindow   time.Duration
+	BurstMinLikes int
+	BurstNewRatio float64
+	BurstDiscount float64
+	// A like is discounted by CoLikeDiscount when at least CoLikeMinUsers of
+	// the post's recent likers also liked CoLikeMinShared other posts with
+	// the same user within CoLikeWindow.
+	CoLikeWindow    time.Duration
+	CoLikeMinShared int
+	CoLikeMinUsers  int
+	CoLikeDiscount  float64
+}
+
+type likeFraudUsecase struct {
+	userRepo repository.UserRepository
+	likeRepo repository.LikeRepository
+	flagRepo repository.LikeFlagRepository
+	opts     LikeFraudOptions
+}
+
+func NewLikeFraudUsecase(
+	userRepo repository.UserRepository,
+	likeRepo repository.LikeRepository,
+	flagRepo repository.LikeFlagRepository,
+	opts LikeFraudOptions,
+) LikeFraudUsecase {
+	return &likeFraudUsecase{
+		userRepo: userRepo,
+		likeRepo: likeRepo,
+		flagRepo: flagRepo,
+		opts:     opts,
+	}
+}
+
+// Assess combines the checks that fire by multiplying the share each one
+// keeps, so several weak signals add up without going past a full discount.
+func (uc *likeFraudUsecase) Assess(ctx context.Context, like *entity.Like) (float64, error) {
+	user, err := uc.userRepo.FindByID(ctx, like.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := 1.0
+	var reasons []string
+	isNew := func(createdAt time.Time) bool {
+		return like.CreatedAt.Sub(createdAt) < uc.opts.NewAccountAge
+	}
+	newAccount := uc.opts.NewAccountDiscount > 0 && isNew(user.CreatedAt)
+	if newAccount {
+		kept *= 1 - uc.opts.NewAccountDiscount
+		reasons = append(reasons, "new_account")
+	}
+
+	// The like itself may or may not be visible to the query yet, depending
+	// on how likes are written, so the liker is always counted separately.
+	var others []uuid.UUID
+	if uc.opts.BurstDiscount > 0 || uc.opts.CoLikeDiscount > 0 {
+		window := uc.opts.BurstWindow
+		if uc.opts.CoLikeWindow > window {
+			window = uc.opts.CoLikeWindow
+		}
+		likers, err := uc.likeRepo.RecentLikers(ctx, like.PostID, like.CreatedAt.Add(-window))
+		if err != nil {
+			return 0, err
+		}
+
+		burstSince := like.CreatedAt.Add(-uc.opts.BurstWindow)
+		coLikeSince := like.CreatedAt.Add(-uc.opts.CoLikeWindow)
+		total, fresh := 1, 0
+		if isNew(user.CreatedAt) {
+			fresh++
+		}
+		for _, l := range likers {
+			if l.UserID == like.UserID {
+				continue
+			}
+			if l.LikedAt.After(coLikeSince) {
+				others = append(others, l.UserID)
+			}
+			if l.LikedAt.After(burstSince) {
+				total++
+				if isNew(l.UserCreatedAt) {
+					fresh++
+				}
+			}
+		}
+
+		if uc.opts.BurstDiscount > 0 && newAccount && total >= uc.opts.BurstMinLikes &&
+			float64(fresh)/float64(total) >= uc.opts.BurstNewRatio {
+			kept *= 1 - uc.opts.BurstDiscount
+			reasons = append(reasons, "new_account_burst")
+		}
+	}
+
+	if uc.opts.CoLikeDiscount > 0 && len(others) >= uc.opts.CoLikeMinUsers {
+		partners, err := uc.likeRepo.CoLikers(ctx, like.UserID, like.PostID, others,
+			like.CreatedAt.Add(-uc.opts.CoLikeWindow), uc.opts.CoLikeMinShared)
+		if err != nil {
+			return 0, err
+		}
+		if partners >= int64(uc.opts.CoLikeMinUsers) {
+			kept *= 1 - uc.opts.CoLikeDiscount
+			reasons = append(reasons, "co_like_ring")
+		}
+	}
+
+	discount := 1 - kept
+	if discount <= 0 {
+		return 0, nil
+	}
+	err = uc.flagRepo.Add(ctx, &entity.LikeFlag{
+		LikeID:   like.ID,
+		PostID:   like.PostID,
+		UserID:   like.UserID,
+		LikedAt:  like.CreatedAt,
+		Discount: discount,
+		Reasons:  strings.Join(reasons, ","),
+	})
+	if err != nil {
+		return 0, err
+	}
+	likesFlagged.Add(1)
+	return discount, nil
+}
+
+func (uc *likeFraudUsecase) Release(ctx context.Context, like *entity.Like) (float64, error) {
+	flag, err := uc.flagRepo.Remove(ctx, like.ID)
+	if err != nil || flag == nil {
+		return 0, err
+	}
+	return flag.Discount, nil
+}
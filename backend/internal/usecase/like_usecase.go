@@ -2,33 +2,135 @@ package usecase
 
 import (
 	"backend/internal/entity"
+	"backend/internal/event"
 	"backend/internal/repository"
+	"backend/pkg/pagination"
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type LikeUsecase interface {
-	ToggleLike(ctx context.Context, postID, userID uuid.UUID) error
-	GetLikesByPost(ctx context.Context, postID uuid.UUID) ([]entity.Like, error)
+	ToggleLike(ctx context.Context, postID, userID uuid.UUID) (bool, error)
+	LikePost(ctx context.Context, postID, userID uuid.UUID) error
+	UnlikePost(ctx context.Context, postID, userID uuid.UUID) error
+	GetLikesByPost(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Like, pagination.Info, error)
 }
 
 type likeUsecase struct {
 	likeRepo repository.LikeRepository
+	postRepo repository.PostRepository
+	fraud    LikeFraudUsecase
+	tx       repository.Transactor
+	events   event.Publisher
 }
 
-func NewLikeUsecase(likeRepo repository.LikeRepository) LikeUsecase {
-	return &likeUsecase{likeRepo: likeRepo}
+func NewLikeUsecase(
+	likeRepo repository.LikeRepository,
+	postRepo repository.PostRepository,
+	fraud LikeFraudUsecase,
+	tx repository.Transactor,
+	events event.Publisher,
+) LikeUsecase {
+	return &likeUsecase{
+		likeRepo: likeRepo,
+		postRepo: postRepo,
+		fraud:    fraud,
+		tx:       tx,
+		events:   events,
+	}
+}
+
+// ToggleLike flips the user's like and reports whether the post ends up
+// liked.
+func (uc *likeUsecase) ToggleLike(ctx context.Context, postID, userID uuid.UUID) (bool, error) {
+	like := &entity.Like{
+		PostID: postID,
+		UserID: userID,
+	}
+	var liked bool
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var changed bool
+		var err error
+		liked, changed, err = uc.likeRepo.ToggleLike(ctx, like)
+		if err != nil || !changed {
+			return err
+		}
+		return uc.publishLike(ctx, like, liked)
+	})
+	return liked, err
 }
 
-func (uc *likeUsecase) ToggleLike(ctx context.Context, postID, userID uuid.UUID) error {
+// LikePost is idempotent: liking an already liked post changes nothing.
+func (uc *likeUsecase) LikePost(ctx context.Context, postID, userID uuid.UUID) error {
 	like := &entity.Like{
 		PostID: postID,
 		UserID: userID,
 	}
-	return uc.likeRepo.ToggleLike(ctx, like)
+	return uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		created, err := uc.likeRepo.Like(ctx, like)
+		if err != nil || !created {
+			return err
+		}
+		return uc.publishLike(ctx, like, true)
+	})
+}
+
+// UnlikePost is idempotent: unliking a post that is not liked changes nothing.
+func (uc *likeUsecase) UnlikePost(ctx context.Context, postID, userID uuid.UUID) error {
+	like := &entity.Like{
+		PostID: postID,
+		UserID: userID,
+	}
+	return uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		removed, err := uc.likeRepo.Unlike(ctx, like)
+		if err != nil || !removed {
+			return err
+		}
+		return uc.publishLike(ctx, like, false)
+	})
+}
+
+// publishLike runs the fraud checks on a new like, or clears the flag of a
+// removed one, so the event tells trending how much of the like to count.
+// The like is recorded either way; the author's karma moves with the like
+// rows in the repository.
+func (uc *likeUsecase) publishLike(ctx context.Context, like *entity.Like, liked bool) error {
+	authorID, err := uc.postRepo.GetAuthorID(ctx, like.PostID)
+	if err != nil {
+		return err
+	}
+	if liked {
+		discount, err := uc.fraud.Assess(ctx, like)
+		if err != nil {
+			return err
+		}
+		return uc.events.Publish(ctx, event.LikeAdded{
+			LikeID:   like.ID,
+			PostID:   like.PostID,
+			AuthorID: authorID,
+			UserID:   like.UserID,
+			LikedAt:  like.CreatedAt,
+			Discount: discount,
+		})
+	}
+
+	discount, err := uc.fraud.Release(ctx, like)
+	if err != nil {
+		return err
+	}
+	return uc.events.Publish(ctx, event.LikeRemoved{
+		LikeID:   like.ID,
+		PostID:   like.PostID,
+		AuthorID: authorID,
+		UserID:   like.UserID,
+		LikedAt:  like.CreatedAt,
+		Discount: discount,
+		At:       time.Now(),
+	})
 }
 
-func (uc *likeUsecase) GetLikesByPost(ctx context.Context, postID uuid.UUID) ([]entity.Like, error) {
-	return uc.likeRepo.FindByPostID(ctx, postID)
+func (uc *likeUsecase) GetLikesByPost(ctx context.Context, postID uuid.UUID, page pagination.Params) ([]entity.Like, pagination.Info, error) {
+	return uc.likeRepo.ListByPost(ctx, postID, page)
 }
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"backend/internal/entity"
+	"backend/internal/event"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxUsecase makes event delivery survive crashes. Publish saves the event
+// in the outbox within the caller's transaction, and the relay later hands
+// saved events to the bus until the bus accepts them. Every attempt carries
+// the entry ID as the delivery key, so consumers can drop repeats.
+type OutboxUsecase interface {
+	event.Publisher
+	// Relay delivers one batch of due entries and returns how many it
+	// handled.
+	Relay(ctx context.Context, batch int) (int, error)
+	RunRelay(ctx context.Context, interval time.Duration, batch int)
+}
+
+type OutboxOptions struct {
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt; it doubles with
+	// every further failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retention is how long delivered entries are kept before being purged.
+	Retention time.Duration
+}
+
+type outboxUsecase struct {
+	tx         repository.Transactor
+	outboxRepo repository.OutboxRepository
+	bus        event.Publisher
+	opts       OutboxOptions
+}
+
+func NewOutboxUsecase(tx repository.Transactor, outboxRepo repository.OutboxRepository, bus event.Publisher, opts OutboxOptions) OutboxUsecase {
+	return &outboxUsecase{
+		tx:         tx,
+		outboxRepo: outboxRepo,
+		bus:        bus,
+		opts:       opts,
+	}
+}
+
+func (uc *outboxUsecase) Publish(ctx context.Context, e event.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return uc.outboxRepo.Add(ctx, &entity.OutboxEntry{
+		EventType: string(e.Type()),
+		Payload:   payload,
+	})
+}
+
+func (uc *outboxUsecase) Relay(ctx context.Context, batch int) (int, error) {
+	var handled int
+	err := uc.tx.WithinTx(ctx, func(txCtx context.Context) error {
+		entries, err := uc.outboxRepo.ClaimDue(txCtx, batch, uc.opts.MaxAttempts)
+		if err != nil {
+			return err
+		}
+		handled = len(entries)
+
+		delivered := make([]uuid.UUID, 0, len(entries))
+		for _, entry := range entries {
+			// Deliver outside the relay's transaction: subscribers run with
+			// this context on the sync bus and must not join it.
+			if err := uc.deliver(event.WithKey(ctx, entry.ID.String()), entry); err != nil {
+				if err := uc.fail(txCtx, entry, err); err != nil {
+					return err
+				}
+				continue
+			}
+			delivered = append(delivered, entry.ID)
+		}
+		return uc.outboxRepo.MarkDelivered(txCtx, delivered)
+	})
+	return handled, err
+}
+
+func (uc *outboxUsecase) deliver(ctx context.Context, entry entity.OutboxEntry) error {
+	e, err := event.Decode(event.Type(entry.EventType), entry.Payload)
+	if err != nil {
+		return err
+	}
+	return uc.bus.Publish(ctx, e)
+}
+
+func (uc *outboxUsecase) fail(ctx context.Context, entry entity.OutboxEntry, cause error) error {
+	attempts := entry.Attempts + 1
+	if attempts >= uc.opts.MaxAttempts {
+		log.Printf("❌ Giving up on outbox entry %s (%s) after %d attempts: %v", entry.ID, entry.EventType, attempts, cause)
+	} else {
+		log.Printf("⚠️ Failed to relay outbox entry %s (%s), attempt %d: %v", entry.ID, entry.EventType, attempts, cause)
+	}
+
+	backoff := uc.opts.Backoff << (attempts - 1)
+	if backoff <= 0 || backoff > uc.opts.MaxBackoff {
+		backoff = uc.opts.MaxBackoff
+	}
+	return uc.outboxRepo.MarkFailed(ctx, entry.ID, time.Now().Add(backoff), cause.Error())
+}
+
+// RunRelay relays due entries every interval and purges old delivered ones
+// every hour until ctx is done.
+func (uc *outboxUsecase) RunRelay(ctx context.Context, interval time.Duration, batch int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep going while full batches come back so a backlog clears
+			// without waiting for more ticks.
+			for {
+				n, err := uc.Relay(ctx, batch)
+				if err != nil {
+					log.Printf("⚠️ Failed to relay outbox: %v", err)
+				}
+				if err != nil || n < batch {
+					break
+				}
+			}
+		case <-purge.C:
+			purged, err := uc.outboxRepo.PurgeDelivered(ctx, time.Now().Add(-uc.opts.Retention))
+			if err != nil {
+				log.Printf("⚠️ Failed to purge delivered outbox entries: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("🔧 Purged %d delivered outbox entries", purged)
+			}
+		}
+	}
+}
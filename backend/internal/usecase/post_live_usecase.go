@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Live update types. A subscriber's first update is always a snapshot.
+const (
+	PostLiveSnapshot       = "snapshot"
+	PostLiveLiked          = "liked"
+	PostLiveUnliked        = "unliked"
+	PostLiveCommentCreated = "comment_created"
+	PostLiveCommentDeleted = "comment_deleted"
+)
+
+// postLiveBuffer is how many updates a slow viewer may fall behind before
+// older ones are dropped. Every update carries the full counts, so the newest
+// one is always enough to catch up.
+const postLiveBuffer = 8
+
+type PostLiveUpdate struct {
+	Type         string    `json:"type"`
+	PostID       uuid.UUID `json:"post_id"`
+	LikeCount    int64     `json:"like_count"`
+	CommentCount int64     `json:"comment_count"`
+	At           time.Time `json:"at"`
+}
+
+type PostLiveUsecase interface {
+	// Start relays published updates to local viewers until ctx is done.
+	Start(ctx context.Context) error
+	// Publish sends the post's current counts to everyone viewing it.
+	Publish(ctx context.Context, postID uuid.UUID, updateType string) error
+	// Subscribe streams encoded updates about one post, starting with a
+	// snapshot, until ctx is done.
+	Subscribe(ctx context.Context, postID uuid.UUID) (<-chan []byte, error)
+}
+
+type postLiveUsecase struct {
+	feed        repository.PostLiveFeed
+	likeRepo    repository.LikeRepository
+	commentRepo repository.CommentRepository
+
+	mu      sync.Mutex
+	viewers map[uuid.UUID]map[chan []byte]struct{}
+}
+
+func NewPostLiveUsecase(feed repository.PostLiveFeed, likeRepo repository.LikeRepository, commentRepo repository.CommentRepository) PostLiveUsecase {
+	return &postLiveUsecase{
+		feed:        feed,
+		likeRepo:    likeRepo,
+		commentRepo: commentRepo,
+		viewers:     make(map[uuid.UUID]map[chan []byte]struct{}),
+	}
+}
+
+func (uc *postLiveUsecase) Start(ctx context.Context) error {
+	messages, err := uc.feed.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	go uc.relay(messages)
+	return nil
+}
+
+func (uc *postLiveUsecase) relay(messages <-chan repository.PostLiveMessage) {
+	for msg := range messages {
+		uc.mu.Lock()
+		for ch := range uc.viewers[msg.PostID] {
+			offerLatest(ch, msg.Data)
+		}
+		uc.mu.Unlock()
+	}
+}
+
+func (uc *postLiveUsecase) Publish(ctx context.Context, postID uuid.UUID, updateType string) error {
+	data, err := uc.encode(ctx, postID, updateType)
+	if err != nil {
+		return err
+	}
+	return uc.feed.Publish(ctx, postID, data)
+}
+
+func (uc *postLiveUsecase) Subscribe(ctx context.Context, postID uuid.UUID) (<-chan []byte, error) {
+	// Register before taking the snapshot so no update in between is lost.
+	updates := make(chan []byte, postLiveBuffer)
+	uc.mu.Lock()
+	if uc.viewers[postID] == nil {
+		uc.viewers[postID] = make(map[chan []byte]struct{})
+	}
+	uc.viewers[postID][updates] = struct{}{}
+	uc.mu.Unlock()
+	unsubscribe := func() {
+		uc.mu.Lock()
+		delete(uc.viewers[postID], updates)
+		if len(uc.viewers[postID]) == 0 {
+			delete(uc.viewers, postID)
+		}
+		uc.mu.Unlock()
+	}
+
+	snapshot, err := uc.encode(ctx, postID, PostLiveSnapshot)
+	if err != nil {
+		unsubscribe()
+		return nil, err
+	}
+
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		next := snapshot
+		for {
+			select {
+			case out <- next:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case next = <-updates:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (uc *postLiveUsecase) encode(ctx context.Context, postID uuid.UUID, updateType string) ([]byte, error) {
+	likes, err := uc.likeRepo.CountByPostIDs(ctx, []uuid.UUID{postID})
+	if err != nil {
+		return nil, err
+	}
+	comments, err := uc.commentRepo.CountByPostID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(PostLiveUpdate{
+		Type:         updateType,
+		PostID:       postID,
+		LikeCount:    likes[postID],
+		CommentCount: comments,
+		At:           time.Now(),
+	})
+}
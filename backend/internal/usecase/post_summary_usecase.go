@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostSummary is how posts are listed: counts of their likes and comments
+// rather than the rows, which have their own paginated endpoints.
+type PostSummary struct {
+	ID           uuid.UUID       `json:"id"`
+	Author       entity.UserInfo `json:"author"`
+	Title        string          `json:"title"`
+	Content      string          `json:"content"`
+	ImageURL     string          `json:"image_url,omitempty"`
+	Tags         []string        `json:"tags"`
+	LikeCount    int64           `json:"like_count"`
+	CommentCount int64           `json:"comment_count"`
+	UniqueViews  int64           `json:"unique_views"`
+	LikedByMe    bool            `json:"liked_by_me"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+type PostSummaryUsecase interface {
+	// Summarize turns posts into summaries, in the same order. The counts are
+	// the posts' own counter columns, so likes still in the Redis buffer show
+	// up after the next flush. LikedByMe is only set for a non-nil viewerID.
+	Summarize(ctx context.Context, posts []entity.Post, viewerID uuid.UUID) ([]PostSummary, error)
+}
+
+type postSummaryUsecase struct {
+	likeRepo repository.LikeRepository
+	userRepo repository.UserRepository
+	viewUC   ViewUsecase
+}
+
+func NewPostSummaryUsecase(likeRepo repository.LikeRepository, userRepo repository.UserRepository, viewUC ViewUsecase) PostSummaryUsecase {
+	return &postSummaryUsecase{
+		likeRepo: likeRepo,
+		userRepo: userRepo,
+		viewUC:   viewUC,
+	}
+}
+
+func (uc *postSummaryUsecase) Summarize(ctx context.Context, posts []entity.Post, viewerID uuid.UUID) ([]PostSummary, error) {
+	summaries := make([]PostSummary, len(posts))
+	if len(posts) == 0 {
+		return summaries, nil
+	}
+
+	ids := make([]uuid.UUID, len(posts))
+	seen := make(map[uuid.UUID]bool)
+	var authorIDs []uuid.UUID
+	for i, p := range posts {
+		ids[i] = p.ID
+		if !seen[p.AuthorID] {
+			seen[p.AuthorID] = true
+			authorIDs = append(authorIDs, p.AuthorID)
+		}
+	}
+
+	liked := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		var err error
+		if liked, err = uc.likeRepo.LikedByUser(ctx, viewerID, ids); err != nil {
+			return nil, err
+		}
+	}
+	users, err := uc.userRepo.FindByIDs(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[uuid.UUID]entity.UserInfo, len(users))
+	for _, u := range users {
+		authors[u.ID] = entity.UserInfo{ID: u.ID, Username: u.Username, ProfilePicURL: u.ProfilePicURL}
+	}
+	// Views are best effort, like everywhere else they are shown.
+	if err := uc.viewUC.FillUniqueViews(ctx, posts); err != nil {
+		log.Printf("⚠️ Failed to load unique views of posts: %v", err)
+	}
+
+	for i, p := range posts {
+		tags := make([]string, len(p.Tags))
+		for j, t := range p.Tags {
+			tags[j] = t.Name
+		}
+		author, ok := authors[p.AuthorID]
+		if !ok {
+			author = entity.UserInfo{ID: p.AuthorID}
+		}
+		summaries[i] = PostSummary{
+			ID:           p.ID,
+			Author:       author,
+			Title:        p.Title,
+			Content:      p.Content,
+			ImageURL:     p.ImageURL,
+			Tags:         tags,
+			LikeCount:    p.LikeCount,
+			CommentCount: p.CommentCount,
+			UniqueViews:  p.UniqueViews,
+			LikedByMe:    liked[p.ID],
+			CreatedAt:    p.CreatedAt,
+			UpdatedAt:    p.UpdatedAt,
+		}
+	}
+	return summaries, nil
+}
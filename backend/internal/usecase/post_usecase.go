@@ -2,8 +2,13 @@ package usecase
 
 import (
 	"backend/internal/entity"
+	"backend/internal/event"
 	"backend/internal/repository"
+	"backend/pkg/hashtag"
+	"backend/pkg/pagination"
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,35 +16,172 @@ import (
 type PostUsecase interface {
 	CreatePost(ctx context.Context, post *entity.Post) error
 	GetPostByID(ctx context.Context, id uuid.UUID) (*entity.Post, error)
-	GetAllPosts(ctx context.Context) ([]entity.Post, error)
+	ListPosts(ctx context.Context, query PostQuery, page pagination.Params) ([]entity.Post, pagination.Info, error)
 	UpdatePost(ctx context.Context, post *entity.Post) error
 	DeletePost(ctx context.Context, id uuid.UUID) error
 }
 
+var (
+	ErrInvalidSort  = errors.New("unknown post sort")
+	ErrInvalidRange = errors.New("since must be before until")
+)
+
+// PostQuery filters and orders a post list. Zero fields do not filter, and
+// an empty Sort lists the newest first.
+type PostQuery struct {
+	AuthorID uuid.UUID
+	Since    time.Time
+	Until    time.Time
+	HasImage *bool
+	Tag      string
+	Text     string
+	Sort     string
+}
+
+var postSorts = map[string]repository.PostSort{
+	"":               repository.PostSortNew,
+	"new":            repository.PostSortNew,
+	"old":            repository.PostSortOld,
+	"top":            repository.PostSortTop,
+	"trending":       repository.PostSortTrending,
+	"most_commented": repository.PostSortMostCommented,
+}
+
+type PostOptions struct {
+	// Gravity is how fast age sinks posts listed by trending.
+	Gravity float64
+}
+
 type postUsecase struct {
 	postRepo repository.PostRepository
+	tagRepo  repository.TagRepository
+	tx       repository.Transactor
+	events   event.Publisher
+	opts     PostOptions
 }
 
-func NewPostUsecase(postRepo repository.PostRepository) PostUsecase {
-	return &postUsecase{postRepo: postRepo}
+func NewPostUsecase(postRepo repository.PostRepository, tagRepo repository.TagRepository, tx repository.Transactor, events event.Publisher, opts PostOptions) PostUsecase {
+	return &postUsecase{
+		postRepo: postRepo,
+		tagRepo:  tagRepo,
+		tx:       tx,
+		events:   events,
+		opts:     opts,
+	}
 }
 
+// CreatePost tags the post with the hashtags in its title and content.
 func (u *postUsecase) CreatePost(ctx context.Context, post *entity.Post) error {
-	return u.postRepo.Create(post)
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		tags, err := u.tagRepo.Upsert(ctx, hashtag.Parse(post.Title, post.Content))
+		if err != nil {
+			return err
+		}
+		post.Tags = tags
+		if err := u.postRepo.Create(ctx, post); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, event.PostCreated{
+			PostID:   post.ID,
+			AuthorID: post.AuthorID,
+			At:       post.CreatedAt,
+		})
+	})
 }
 
 func (u *postUsecase) GetPostByID(ctx context.Context, id uuid.UUID) (*entity.Post, error) {
-	return u.postRepo.GetByID(id)
+	return u.postRepo.GetByID(ctx, id)
 }
 
-func (u *postUsecase) GetAllPosts(ctx context.Context) ([]entity.Post, error) {
-	return u.postRepo.GetAll()
+func (u *postUsecase) ListPosts(ctx context.Context, query PostQuery, page pagination.Params) ([]entity.Post, pagination.Info, error) {
+	sort, ok := postSorts[query.Sort]
+	if !ok {
+		return nil, pagination.Info{}, ErrInvalidSort
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && !query.Since.Before(query.Until) {
+		return nil, pagination.Info{}, ErrInvalidRange
+	}
+	return u.postRepo.List(ctx, repository.PostQuery{
+		AuthorID: query.AuthorID,
+		Since:    query.Since,
+		Until:    query.Until,
+		HasImage: query.HasImage,
+		Tag:      query.Tag,
+		Text:     query.Text,
+		Sort:     sort,
+		Gravity:  u.opts.Gravity,
+	}, page)
 }
 
+// UpdatePost re-reads the hashtags of the edited title and content.
 func (u *postUsecase) UpdatePost(ctx context.Context, post *entity.Post) error {
-	return u.postRepo.Update(post)
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := u.tagRepo.NamesByPost(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		names := hashtag.Parse(post.Title, post.Content)
+		tags, err := u.tagRepo.Upsert(ctx, names)
+		if err != nil {
+			return err
+		}
+
+		if err := u.postRepo.Update(ctx, post); err != nil {
+			return err
+		}
+		if err := u.tagRepo.SetPostTags(ctx, post, tags); err != nil {
+			return err
+		}
+		post.Tags = tags
+
+		added, removed := diffTags(before, names)
+		if len(added) == 0 && len(removed) == 0 {
+			return nil
+		}
+		return u.events.Publish(ctx, event.PostRetagged{
+			PostID:  post.ID,
+			Added:   added,
+			Removed: removed,
+			At:      time.Now(),
+		})
+	})
 }
 
 func (u *postUsecase) DeletePost(ctx context.Context, id uuid.UUID) error {
-	return u.postRepo.Delete(id)
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		tags, err := u.tagRepo.NamesByPost(ctx, id)
+		if err != nil {
+			return err
+		}
+		authorID, err := u.postRepo.GetAuthorID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := u.postRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.events.Publish(ctx, event.PostDeleted{PostID: id, AuthorID: authorID, Tags: tags, At: time.Now()})
+	})
+}
+
+// diffTags returns the tags in after but not before, and those in before
+// but not after.
+func diffTags(before, after []string) (added, removed []string) {
+	had := make(map[string]bool, len(before))
+	for _, tag := range before {
+		had[tag] = true
+	}
+	for _, tag := range after {
+		if had[tag] {
+			delete(had, tag)
+		} else {
+			added = append(added, tag)
+		}
+	}
+	for _, tag := range before {
+		if had[tag] {
+			removed = append(removed, tag)
+		}
+	}
+	return added, removed
 }
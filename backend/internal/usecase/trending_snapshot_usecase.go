@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNoSnapshot = errors.New("no trending snapshot at or before that time")
+
+type TrendingSnapshotUsecase interface {
+	// TakeSnapshot archives the current all-time and windowed Top-K.
+	TakeSnapshot(ctx context.Context) error
+	// GetHistory returns the ranking of window as of the latest snapshot
+	// taken at or before at.
+	GetHistory(ctx context.Context, at time.Time, window string) (*TrendingHistory, error)
+	// Run takes a snapshot every interval and prunes expired ones until ctx
+	// is done.
+	Run(ctx context.Context)
+}
+
+type TrendingSnapshotOptions struct {
+	K         int
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+type TrendingHistory struct {
+	TakenAt time.Time      `json:"taken_at"`
+	Window  string         `json:"window"`
+	Posts   []TrendingPost `json:"posts"`
+}
+
+type trendingSnapshotUsecase struct {
+	trendingUC   TrendingUsecase
+	snapshotRepo repository.TrendingSnapshotRepository
+	postRepo     repository.PostRepository
+	summaryUC    PostSummaryUsecase
+	opts         TrendingSnapshotOptions
+}
+
+func NewTrendingSnapshotUsecase(
+	trendingUC TrendingUsecase,
+	snapshotRepo repository.TrendingSnapshotRepository,
+	postRepo repository.PostRepository,
+	summaryUC PostSummaryUsecase,
+	opts TrendingSnapshotOptions,
+) TrendingSnapshotUsecase {
+	return &trendingSnapshotUsecase{
+		trendingUC:   trendingUC,
+		snapshotRepo: snapshotRepo,
+		postRepo:     postRepo,
+		summaryUC:    summaryUC,
+		opts:         opts,
+	}
+}
+
+// TakeSnapshot stamps every ranking with the start of the current interval,
+// so instances that snapshot in the same interval write the same rows and
+// only the first is kept.
+func (uc *trendingSnapshotUsecase) TakeSnapshot(ctx context.Context) error {
+	takenAt := time.Now().Truncate(uc.opts.Interval)
+
+	windows := []string{""}
+	for window := range trendingWindows {
+		windows = append(windows, window)
+	}
+
+	var rows []entity.TrendingSnapshot
+	for _, window := range windows {
+		posts, err := uc.trendingUC.GetTrending(ctx, TrendingQuery{K: uc.opts.K, Algo: AlgoRaw, Window: window})
+		if err != nil {
+			return err
+		}
+		for _, p := range posts {
+			rows = append(rows, entity.TrendingSnapshot{
+				TakenAt: takenAt,
+				Window:  window,
+				Rank:    p.Rank,
+				PostID:  p.Post.ID,
+				Score:   p.Score,
+			})
+		}
+	}
+	return uc.snapshotRepo.Save(ctx, rows)
+}
+
+func (uc *trendingSnapshotUsecase) GetHistory(ctx context.Context, at time.Time, window string) (*TrendingHistory, error) {
+	if _, ok := trendingWindows[window]; window != "" && !ok {
+		return nil, ErrInvalidWindow
+	}
+
+	rows, err := uc.snapshotRepo.FindAt(ctx, window, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoSnapshot
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.PostID
+	}
+	posts, err := uc.postRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := uc.summaryUC.Summarize(ctx, posts, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]PostSummary, len(summaries))
+	for _, p := range summaries {
+		byID[p.ID] = p
+	}
+
+	// Ranks are kept as archived; posts deleted since are left out rather
+	// than closing the gap they leave.
+	history := &TrendingHistory{TakenAt: rows[0].TakenAt, Window: window}
+	for _, row := range rows {
+		post, ok := byID[row.PostID]
+		if !ok {
+			continue
+		}
+		history.Posts = append(history.Posts, TrendingPost{
+			Rank:  row.Rank,
+			Score: row.Score,
+			Post:  post,
+		})
+	}
+	return history, nil
+}
+
+func (uc *trendingSnapshotUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.TakeSnapshot(ctx); err != nil {
+			log.Printf("⚠️ Failed to snapshot trending posts: %v", err)
+		}
+		purged, err := uc.snapshotRepo.PurgeBefore(ctx, time.Now().Add(-uc.opts.Retention))
+		if err != nil {
+			log.Printf("⚠️ Failed to prune trending snapshots: %v", err)
+		} else if purged > 0 {
+			log.Printf("🔧 Pruned %d trending snapshot rows", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
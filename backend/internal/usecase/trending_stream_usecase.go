@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trendingStreamBuffer is how many events a slow subscriber may fall behind
+// before older ones are dropped. Every event is a full snapshot, so only the
+// newest one matters.
+const trendingStreamBuffer = 4
+
+type TrendingStreamUsecase interface {
+	// Start publishes the leaderboard whenever its ranking changes and
+	// relays published snapshots to local subscribers until ctx is done.
+	Start(ctx context.Context) error
+	// Subscribe streams snapshots to one client. With a lastEventID it
+	// replays the retained snapshots after it; without one it starts from
+	// the latest.
+	Subscribe(ctx context.Context, lastEventID int64) (<-chan repository.TrendingEvent, error)
+}
+
+type TrendingStreamOptions struct {
+	K int
+	// Interval is the minimum time between two published snapshots, across
+	// all instances.
+	Interval time.Duration
+}
+
+type trendingStreamUsecase struct {
+	trendingUC TrendingUsecase
+	feed       repository.TrendingFeed
+	opts       TrendingStreamOptions
+
+	mu          sync.Mutex
+	subscribers map[chan repository.TrendingEvent]struct{}
+}
+
+func NewTrendingStreamUsecase(trendingUC TrendingUsecase, feed repository.TrendingFeed, opts TrendingStreamOptions) TrendingStreamUsecase {
+	return &trendingStreamUsecase{
+		trendingUC:  trendingUC,
+		feed:        feed,
+		opts:        opts,
+		subscribers: make(map[chan repository.TrendingEvent]struct{}),
+	}
+}
+
+func (uc *trendingStreamUsecase) Start(ctx context.Context) error {
+	events, err := uc.feed.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	go uc.relay(events)
+	go uc.runPublisher(ctx)
+	return nil
+}
+
+func (uc *trendingStreamUsecase) runPublisher(ctx context.Context) {
+	ticker := time.NewTicker(uc.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := uc.publish(ctx); err != nil {
+				log.Printf("⚠️ Failed to publish trending snapshot: %v", err)
+			}
+		}
+	}
+}
+
+// publish sends the current Top-K if its ranking differs from the last
+// published one. Only the instance holding the feed lock publishes in a given
+// interval; the lock expires a little early so this instance's next tick is
+// not locked out by its own previous claim.
+func (uc *trendingStreamUsecase) publish(ctx context.Context) error {
+	locked, err := uc.feed.Lock(ctx, uc.opts.Interval*9/10)
+	if err != nil || !locked {
+		return err
+	}
+
+	posts, err := uc.trendingUC.GetTrending(ctx, TrendingQuery{K: uc.opts.K})
+	if err != nil {
+		return err
+	}
+	signature := rankingSignature(posts)
+
+	latest, err := uc.feed.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.Signature == signature {
+		return nil
+	}
+
+	data, err := json.Marshal(posts)
+	if err != nil {
+		return err
+	}
+	_, err = uc.feed.Publish(ctx, signature, data)
+	return err
+}
+
+// relay hands every published event to the local subscribers.
+func (uc *trendingStreamUsecase) relay(events <-chan repository.TrendingEvent) {
+	for event := range events {
+		uc.mu.Lock()
+		for ch := range uc.subscribers {
+			offerLatest(ch, event)
+		}
+		uc.mu.Unlock()
+	}
+}
+
+// offerLatest sends v without blocking, dropping the oldest queued value if
+// ch is full.
+func offerLatest[T any](ch chan T, v T) {
+	for {
+		select {
+		case ch <- v:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+func (uc *trendingStreamUsecase) Subscribe(ctx context.Context, lastEventID int64) (<-chan repository.TrendingEvent, error) {
+	// Register before reading the history so that an event published in
+	// between arrives live instead of being lost.
+	live := make(chan repository.TrendingEvent, trendingStreamBuffer)
+	uc.mu.Lock()
+	uc.subscribers[live] = struct{}{}
+	uc.mu.Unlock()
+	unsubscribe := func() {
+		uc.mu.Lock()
+		delete(uc.subscribers, live)
+		uc.mu.Unlock()
+	}
+
+	var replay []repository.TrendingEvent
+	if lastEventID > 0 {
+		events, err := uc.feed.Since(ctx, lastEventID)
+		if err != nil {
+			unsubscribe()
+			return nil, err
+		}
+		replay = events
+	} else {
+		latest, err := uc.feed.Latest(ctx)
+		if err != nil {
+			unsubscribe()
+			return nil, err
+		}
+		if latest != nil {
+			replay = append(replay, *latest)
+		}
+	}
+
+	out := make(chan repository.TrendingEvent)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		sent := lastEventID
+		send := func(event repository.TrendingEvent) bool {
+			if event.ID <= sent {
+				// Already delivered through the replay.
+				return true
+			}
+			select {
+			case out <- event:
+				sent = event.ID
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, event := range replay {
+			if !send(event) {
+				return
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-live:
+				if !send(event) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// rankingSignature identifies the order of posts in a snapshot. Score changes
+// that leave the order alone do not change it.
+func rankingSignature(posts []TrendingPost) string {
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.Post.ID.String()
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,1415 @@
+package usecase
+
+import (
+	"backend/internal/entity"
+	"backend/internal/repository"
+	"backend/pkg/topk"
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AlgoRaw ranks posts by their all-time like count.
+	AlgoRaw = "raw"
+	// AlgoDecay ranks posts by likes that lose weight as they and the post age.
+	AlgoDecay = "decay"
+	// AlgoSketch ranks posts from the in-process heavy-hitters summary,
+	// without touching Redis.
+	AlgoSketch = "sketch"
+	// AlgoViews ranks posts by unique viewers.
+	AlgoViews = "views"
+	// AlgoEngagement ranks posts by a weighted sum of likes, comments and
+	// unique views, less a penalty for age.
+	AlgoEngagement = "engagement"
+)
+
+const (
+	trendingPostsKey    = repository.TrendingPostsKey
+	trendingViewsKey    = "trending:views"
+	trendingCommentsKey = "trending:comments"
+	trendingAuthorsKey  = "trending:authors"
+	trendingTagsKey     = "trending:tags"
+	// trendingCreatedKey scores each post by its creation time in hours
+	// since the epoch, which lets the age penalty be part of a union.
+	trendingCreatedKey    = "trending:created"
+	trendingEngagementKey = "trending:engagement"
+
+	trendingRisingRecentKey   = "trending:rising:recent"
+	trendingRisingBaselineKey = "trending:rising:baseline"
+	// risingBucket is the resolution of the counts rising posts are ranked
+	// from.
+	risingBucket = time.Minute
+	// risingCandidates caps how many recently active posts are scored.
+	risingCandidates = 1000
+	// explainRankDepth is how deep Explain looks for a post in rankings
+	// that can only be listed from the top.
+	explainRankDepth = 100
+)
+
+var (
+	driftChecked  = expvar.NewInt("trending_drift_checked_total")
+	driftFound    = expvar.NewInt("trending_drift_found_total")
+	driftRepaired = expvar.NewFloat("trending_drift_repaired_score_total")
+)
+
+var (
+	ErrInvalidAlgo       = errors.New("unknown trending algorithm")
+	ErrInvalidWindow     = errors.New("unknown trending window")
+	ErrWindowUnsupported = errors.New("window is not supported by this algorithm")
+	ErrPostNotFound      = errors.New("post not found")
+)
+
+// trendingWindow describes a sliding window as a run of fixed-size buckets.
+type trendingWindow struct {
+	span   time.Duration
+	bucket time.Duration
+}
+
+var trendingWindows = map[string]trendingWindow{
+	"1h":  {span: time.Hour, bucket: 5 * time.Minute},
+	"24h": {span: 24 * time.Hour, bucket: time.Hour},
+	"7d":  {span: 7 * 24 * time.Hour, bucket: time.Hour},
+}
+
+// bucketRetention maps each bucket size to the longest window that reads it,
+// which is how long a bucket has to be kept around.
+var bucketRetention = func() map[time.Duration]time.Duration {
+	retention := make(map[time.Duration]time.Duration)
+	for _, w := range trendingWindows {
+		if w.span > retention[w.bucket] {
+			retention[w.bucket] = w.span
+		}
+	}
+	return retention
+}()
+
+func bucketKey(board string, size time.Duration, start time.Time) string {
+	return fmt.Sprintf("%s:bucket:%d:%d", board, int64(size.Seconds()), start.Unix())
+}
+
+// tagBoardKey is the board of the posts tagged with tag.
+func tagBoardKey(tag string) string {
+	return "trending:tag:" + tag
+}
+
+func windowKey(board, window string) string {
+	return fmt.Sprintf("%s:window:%s", board, window)
+}
+
+type TrendingUsecase interface {
+	// RecordLike counts weight of a like, between 0 and 1; the rest is the
+	// discount the fraud checks gave it.
+	RecordLike(ctx context.Context, postID uuid.UUID, likedAt time.Time, weight float64) error
+	RecordUnlike(ctx context.Context, postID uuid.UUID, likedAt time.Time, weight float64) error
+	RecordView(ctx context.Context, postID uuid.UUID, viewedAt time.Time) error
+	RecordComment(ctx context.Context, postID uuid.UUID, commentedAt time.Time) error
+	RecordUncomment(ctx context.Context, postID uuid.UUID, commentedAt time.Time) error
+	RecordPost(ctx context.Context, postID uuid.UUID, createdAt time.Time) error
+	// RecordKarma moves the author's score by delta for a like made at
+	// likedAt on one of their posts.
+	RecordKarma(ctx context.Context, authorID uuid.UUID, likedAt time.Time, delta float64) error
+	// RetagPost moves a post between tag boards when its hashtags change.
+	RetagPost(ctx context.Context, postID uuid.UUID, added, removed []string) error
+	RemovePost(ctx context.Context, postID, authorID uuid.UUID, tags []string) error
+	// SetEngagementWeights replaces the weights of the engagement score
+	// while the service is running.
+	SetEngagementWeights(ctx context.Context, weights EngagementWeights) error
+	GetTrending(ctx context.Context, query TrendingQuery) ([]TrendingPost, error)
+	// GetRising ranks posts by how much faster they gained engagement in
+	// the recent period than in the baseline before it.
+	GetRising(ctx context.Context, k int, viewerID uuid.UUID) ([]TrendingPost, error)
+	// GetTrendingAuthors ranks authors by the likes their posts received,
+	// all-time when window is empty.
+	GetTrendingAuthors(ctx context.Context, k int, window string) ([]TrendingAuthor, error)
+	// GetTrendingTags ranks hashtags by the likes of the posts carrying
+	// them, all-time when window is empty.
+	GetTrendingTags(ctx context.Context, k int, window string) ([]TrendingTag, error)
+	// GetTagPosts ranks the posts tagged with tag by their likes.
+	GetTagPosts(ctx context.Context, tag string, k int, viewerID uuid.UUID) ([]TrendingPost, error)
+	// Explain breaks down where a post stands in every algorithm and window,
+	// comparing the stored counts with Postgres.
+	Explain(ctx context.Context, postID uuid.UUID) (*TrendingExplanation, error)
+	Rebuild(ctx context.Context) error
+	DetectDrift(ctx context.Context, sampleSize int) (int, error)
+	RunDriftDetector(ctx context.Context, interval time.Duration, sampleSize int)
+}
+
+// TrendingQuery selects a leaderboard. An empty Window means all-time, and
+// ViewerID, when set, is who the posts' LikedByMe is answered for.
+type TrendingQuery struct {
+	K        int
+	Algo     string
+	Window   string
+	ViewerID uuid.UUID
+}
+
+type TrendingOptions struct {
+	HalfLife time.Duration
+	Gravity  float64
+	// WindowCacheTTL is how long a windowed union is reused before it is
+	// recomputed from the buckets.
+	WindowCacheTTL time.Duration
+	// ScoredByLikeToggle is set when the Redis like toggle script already
+	// moves the all-time score, so RecordLike must not move it again.
+	ScoredByLikeToggle bool
+	Engagement         EngagementWeights
+	Rising             RisingOptions
+}
+
+// RisingOptions compares the engagement rate over the last Recent against
+// the rate over the Baseline before it. Both are whole minutes. Posts with
+// less than MinActivity weighted engagement in the recent period are left
+// out.
+type RisingOptions struct {
+	Recent      time.Duration
+	Baseline    time.Duration
+	MinActivity float64
+}
+
+// EngagementWeights sets what each signal is worth in the engagement score.
+// Age is the score a post loses for every hour since it was created.
+type EngagementWeights struct {
+	Likes    float64 `json:"likes"`
+	Comments float64 `json:"comments"`
+	Views    float64 `json:"views"`
+	Age      float64 `json:"age"`
+}
+
+type TrendingPost struct {
+	Rank  int         `json:"rank"`
+	Score float64     `json:"score"`
+	Post  PostSummary `json:"post"`
+}
+
+type TrendingAuthor struct {
+	Rank   int             `json:"rank"`
+	Score  float64         `json:"score"`
+	Author entity.UserInfo `json:"author"`
+	Karma  int64           `json:"karma"`
+}
+
+type TrendingTag struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+	Tag   string  `json:"tag"`
+}
+
+type TrendingExplanation struct {
+	PostID   uuid.UUID           `json:"post_id"`
+	AgeHours float64             `json:"age_hours"`
+	Weights  EngagementWeights   `json:"weights"`
+	Windows  []WindowExplanation `json:"windows"`
+}
+
+// WindowExplanation covers one window; an empty Window means all-time.
+type WindowExplanation struct {
+	Window      string               `json:"window"`
+	Likes       CountExplanation     `json:"likes"`
+	Comments    CountExplanation     `json:"comments"`
+	Views       float64              `json:"views"`
+	UniqueViews int64                `json:"unique_views"`
+	Engagement  EngagementComponents `json:"engagement"`
+	Decay       DecayComponents      `json:"decay"`
+	Rankings    []AlgoRanking        `json:"rankings"`
+}
+
+// CountExplanation sets a count kept in the trending store beside the same
+// count recomputed from Postgres, net of flagged likes. A non-zero Drift
+// means they disagree.
+type CountExplanation struct {
+	Stored   float64 `json:"stored"`
+	Postgres float64 `json:"postgres"`
+	Drift    float64 `json:"drift"`
+}
+
+// EngagementComponents are the weighted terms that add up to the engagement
+// score. Age is negative: it is the penalty for the post's age.
+type EngagementComponents struct {
+	Likes    float64 `json:"likes"`
+	Comments float64 `json:"comments"`
+	Views    float64 `json:"views"`
+	Age      float64 `json:"age"`
+	Total    float64 `json:"total"`
+}
+
+// DecayComponents split the decay score into the decayed like count and the
+// factor for the post's age.
+type DecayComponents struct {
+	DecayedLikes  float64 `json:"decayed_likes"`
+	GravityFactor float64 `json:"gravity_factor"`
+	Score         float64 `json:"score"`
+}
+
+// AlgoRanking is a post's place under one algorithm. Rank is 0 when the post
+// is not ranked, or for decay and sketch not within explainRankDepth.
+type AlgoRanking struct {
+	Algo  string  `json:"algo"`
+	Rank  int64   `json:"rank"`
+	Score float64 `json:"score"`
+}
+
+type trendingUsecase struct {
+	store       repository.TrendingStore
+	postRepo    repository.PostRepository
+	userRepo    repository.UserRepository
+	likeRepo    repository.LikeRepository
+	commentRepo repository.CommentRepository
+	flagRepo    repository.LikeFlagRepository
+	tagRepo     repository.TagRepository
+	viewUC      ViewUsecase
+	summaryUC   PostSummaryUsecase
+	sketch      topk.TopK
+	opts        TrendingOptions
+	// retention maps each bucket size kept per board to how long it is
+	// kept: the window buckets plus the minute buckets of rising posts.
+	retention map[time.Duration]time.Duration
+
+	mu      sync.RWMutex
+	weights EngagementWeights
+}
+
+func NewTrendingUsecase(
+	store repository.TrendingStore,
+	postRepo repository.PostRepository,
+	userRepo repository.UserRepository,
+	likeRepo repository.LikeRepository,
+	commentRepo repository.CommentRepository,
+	flagRepo repository.LikeFlagRepository,
+	tagRepo repository.TagRepository,
+	viewUC ViewUsecase,
+	summaryUC PostSummaryUsecase,
+	sketch topk.TopK,
+	opts TrendingOptions,
+) TrendingUsecase {
+	retention := make(map[time.Duration]time.Duration, len(bucketRetention)+1)
+	for size, keep := range bucketRetention {
+		retention[size] = keep
+	}
+	if rising := opts.Rising.Recent + opts.Rising.Baseline; rising > retention[risingBucket] {
+		retention[risingBucket] = rising
+	}
+
+	return &trendingUsecase{
+		store:       store,
+		postRepo:    postRepo,
+		userRepo:    userRepo,
+		likeRepo:    likeRepo,
+		commentRepo: commentRepo,
+		flagRepo:    flagRepo,
+		tagRepo:     tagRepo,
+		viewUC:      viewUC,
+		summaryUC:   summaryUC,
+		sketch:      sketch,
+		opts:        opts,
+		retention:   retention,
+		weights:     opts.Engagement,
+	}
+}
+
+func (uc *trendingUsecase) RecordLike(ctx context.Context, postID uuid.UUID, likedAt time.Time, weight float64) error {
+	return uc.recordPostLike(ctx, postID, 1, weight, likedAt)
+}
+
+// RecordUnlike takes the time and weight of the removed like so that the
+// windowed buckets it was counted in are the ones decremented, by as much as
+// they were incremented.
+func (uc *trendingUsecase) RecordUnlike(ctx context.Context, postID uuid.UUID, likedAt time.Time, weight float64) error {
+	return uc.recordPostLike(ctx, postID, -1, weight, likedAt)
+}
+
+// recordPostLike also moves the post on the boards of its tags, and the tags
+// on the tag board.
+func (uc *trendingUsecase) recordPostLike(ctx context.Context, postID uuid.UUID, direction, weight float64, likedAt time.Time) error {
+	// Read the tags before changing anything, so a failed read leaves
+	// nothing half applied.
+	tags, err := uc.tagRepo.NamesByPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	member := postID.String()
+	delta := direction * weight
+	uc.sketch.Add(member, int64(math.Round(delta)))
+	if uc.opts.ScoredByLikeToggle {
+		// The toggle script moved the all-time score by a whole like; take
+		// back the discounted part.
+		if weight != 1 {
+			if err := uc.store.Incr(ctx, trendingPostsKey, member, direction*(weight-1)); err != nil {
+				return err
+			}
+		}
+		err = uc.incrBuckets(ctx, trendingPostsKey, member, delta, likedAt)
+	} else {
+		err = uc.incr(ctx, trendingPostsKey, member, delta, likedAt)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if err := uc.store.Incr(ctx, tagBoardKey(tag), member, delta); err != nil {
+			return err
+		}
+		if err := uc.incr(ctx, trendingTagsKey, tag, delta, likedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordView counts a viewer seen on the post for the first time.
+func (uc *trendingUsecase) RecordView(ctx context.Context, postID uuid.UUID, viewedAt time.Time) error {
+	return uc.incr(ctx, trendingViewsKey, postID.String(), 1, viewedAt)
+}
+
+func (uc *trendingUsecase) RecordComment(ctx context.Context, postID uuid.UUID, commentedAt time.Time) error {
+	return uc.incr(ctx, trendingCommentsKey, postID.String(), 1, commentedAt)
+}
+
+// RecordUncomment takes the time of the deleted comment so that the windowed
+// buckets it was counted in are the ones decremented.
+func (uc *trendingUsecase) RecordUncomment(ctx context.Context, postID uuid.UUID, commentedAt time.Time) error {
+	return uc.incr(ctx, trendingCommentsKey, postID.String(), -1, commentedAt)
+}
+
+func (uc *trendingUsecase) RecordPost(ctx context.Context, postID uuid.UUID, createdAt time.Time) error {
+	return uc.store.Set(ctx, trendingCreatedKey, postID.String(), epochHours(createdAt))
+}
+
+// RecordKarma counts likes on posts that exist; RemovePost takes a deleted
+// post's likes back off its author.
+func (uc *trendingUsecase) RecordKarma(ctx context.Context, authorID uuid.UUID, likedAt time.Time, delta float64) error {
+	return uc.incr(ctx, trendingAuthorsKey, authorID.String(), delta, likedAt)
+}
+
+// RetagPost carries the post's all-time likes over to the boards of its new
+// tags and takes them off the old ones. Tag buckets keep what they counted,
+// so windowed tag scores catch up as they age out.
+func (uc *trendingUsecase) RetagPost(ctx context.Context, postID uuid.UUID, added, removed []string) error {
+	member := postID.String()
+	for _, tag := range removed {
+		score, err := uc.store.Score(ctx, tagBoardKey(tag), member)
+		if err != nil {
+			return err
+		}
+		if err := uc.store.Remove(ctx, tagBoardKey(tag), member); err != nil {
+			return err
+		}
+		if err := uc.store.Incr(ctx, trendingTagsKey, tag, -score); err != nil {
+			return err
+		}
+	}
+
+	score, err := uc.store.Score(ctx, trendingPostsKey, member)
+	if err != nil || score == 0 {
+		return err
+	}
+	for _, tag := range added {
+		if err := uc.store.Set(ctx, tagBoardKey(tag), member, score); err != nil {
+			return err
+		}
+		if err := uc.store.Incr(ctx, trendingTagsKey, tag, score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemovePost drops a deleted post from the all-time boards, including those
+// of the tags it had, and takes its likes off its author's score, all-time
+// and in the buckets they were counted in. Other windowed buckets age out on
+// their own.
+func (uc *trendingUsecase) RemovePost(ctx context.Context, postID, authorID uuid.UUID, tags []string) error {
+	if err := uc.RetagPost(ctx, postID, nil, tags); err != nil {
+		return err
+	}
+	// Events published before authors were carried have none to adjust.
+	if authorID != uuid.Nil {
+		if err := uc.removeKarma(ctx, postID, authorID); err != nil {
+			return err
+		}
+	}
+	for _, board := range []string{trendingPostsKey, trendingCommentsKey, trendingViewsKey, trendingCreatedKey} {
+		if err := uc.store.Remove(ctx, board, postID.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeKarma takes the post's like score, which is net of flagged likes as
+// the author scores are, off the author board and each author bucket still
+// kept.
+func (uc *trendingUsecase) removeKarma(ctx context.Context, postID, authorID uuid.UUID) error {
+	member, author := postID.String(), authorID.String()
+	score, err := uc.store.Score(ctx, trendingPostsKey, member)
+	if err != nil {
+		return err
+	}
+	if score != 0 {
+		if err := uc.store.Incr(ctx, trendingAuthorsKey, author, -score); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for size, retention := range uc.retention {
+		for start := now.Add(-retention).Truncate(size); !start.After(now); start = start.Add(size) {
+			score, err := uc.store.Score(ctx, bucketKey(trendingPostsKey, size, start), member)
+			if err != nil {
+				return err
+			}
+			if score == 0 {
+				continue
+			}
+			key := bucketKey(trendingAuthorsKey, size, start)
+			if err := uc.store.Incr(ctx, key, author, -score); err != nil {
+				return err
+			}
+			if err := uc.store.ExpireAt(ctx, key, start.Add(size+retention)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (uc *trendingUsecase) engagementWeights() EngagementWeights {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	return uc.weights
+}
+
+// SetEngagementWeights also drops the cached engagement boards, which were
+// summed with the old weights.
+func (uc *trendingUsecase) SetEngagementWeights(ctx context.Context, weights EngagementWeights) error {
+	uc.mu.Lock()
+	uc.weights = weights
+	uc.mu.Unlock()
+	return uc.resetEngagement(ctx)
+}
+
+func (uc *trendingUsecase) resetEngagement(ctx context.Context) error {
+	for _, key := range []string{trendingEngagementKey, trendingRisingRecentKey, trendingRisingBaselineKey} {
+		if err := uc.store.Reset(ctx, key); err != nil {
+			return err
+		}
+	}
+	for window := range trendingWindows {
+		if err := uc.store.Reset(ctx, windowKey(trendingEngagementKey, window)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func epochHours(t time.Time) float64 {
+	return float64(t.Unix()) / 3600
+}
+
+// incr adjusts member on board and on the board's time buckets containing at.
+func (uc *trendingUsecase) incr(ctx context.Context, board, member string, delta float64, at time.Time) error {
+	if err := uc.store.Incr(ctx, board, member, delta); err != nil {
+		return err
+	}
+	return uc.incrBuckets(ctx, board, member, delta, at)
+}
+
+// incrBuckets adjusts member on the board's time buckets containing at.
+func (uc *trendingUsecase) incrBuckets(ctx context.Context, board, member string, delta float64, at time.Time) error {
+	now := time.Now()
+	for size, retention := range uc.retention {
+		start := at.Truncate(size)
+		expireAt := start.Add(size + retention)
+		if !expireAt.After(now) {
+			// The bucket has already aged out of every window.
+			continue
+		}
+		key := bucketKey(board, size, start)
+		if err := uc.store.Incr(ctx, key, member, delta); err != nil {
+			return err
+		}
+		if err := uc.store.ExpireAt(ctx, key, expireAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boardTopK ranks the posts of board all-time, or over window when one is
+// given.
+func (uc *trendingUsecase) boardTopK(ctx context.Context, board, window string, k int) ([]repository.PostScore, error) {
+	members, err := uc.boardMembers(ctx, board, window, k)
+	if err != nil {
+		return nil, err
+	}
+	return postScores(members), nil
+}
+
+func (uc *trendingUsecase) boardMembers(ctx context.Context, board, window string, k int) ([]repository.ScoredMember, error) {
+	if window == "" {
+		return uc.store.TopK(ctx, board, k)
+	}
+	return uc.windowTopK(ctx, board, window, k)
+}
+
+// windowTopK ranks board over the named window.
+func (uc *trendingUsecase) windowTopK(ctx context.Context, board, window string, k int) ([]repository.ScoredMember, error) {
+	key, err := uc.windowBoard(ctx, board, window)
+	if err != nil {
+		return nil, err
+	}
+	return uc.store.TopK(ctx, key, k)
+}
+
+// windowBoard sums board's buckets over the named window and returns the key
+// of the result. The union is cached so bursts of reads share one
+// computation.
+func (uc *trendingUsecase) windowBoard(ctx context.Context, board, window string) (string, error) {
+	w := trendingWindows[window]
+	key := windowKey(board, window)
+
+	cached, err := uc.store.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !cached {
+		n := int(w.span / w.bucket)
+		newest := time.Now().Truncate(w.bucket)
+		keys := make([]string, n)
+		for i := range keys {
+			keys[i] = bucketKey(board, w.bucket, newest.Add(-time.Duration(i)*w.bucket))
+		}
+		if err := uc.store.Union(ctx, key, keys, nil, uc.opts.WindowCacheTTL); err != nil {
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// engagementTopK ranks posts by the weighted sum of their likes, comments,
+// unique views and creation time, all-time or over window.
+func (uc *trendingUsecase) engagementTopK(ctx context.Context, window string, k int) ([]repository.PostScore, error) {
+	key, weights, err := uc.engagementBoard(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	members, err := uc.store.TopK(ctx, key, k)
+	if err != nil {
+		return nil, err
+	}
+	// The union adds Age times the creation hour; taking Age times the
+	// current hour off every post turns that into Age times the post's age
+	// without changing the order.
+	scores := postScores(members)
+	offset := weights.Age * epochHours(time.Now())
+	for i := range scores {
+		scores[i].Score -= offset
+	}
+	return scores, nil
+}
+
+// engagementBoard sums the engagement score, all-time or over window, and
+// returns the key of the result with the weights it was summed with. The sum
+// is cached like a windowed board.
+func (uc *trendingUsecase) engagementBoard(ctx context.Context, window string) (string, EngagementWeights, error) {
+	weights := uc.engagementWeights()
+	key := trendingEngagementKey
+	if window != "" {
+		key = windowKey(trendingEngagementKey, window)
+	}
+
+	cached, err := uc.store.Exists(ctx, key)
+	if err != nil || cached {
+		return key, weights, err
+	}
+
+	var keys []string
+	for _, board := range []string{trendingPostsKey, trendingCommentsKey, trendingViewsKey} {
+		if window != "" {
+			if board, err = uc.windowBoard(ctx, board, window); err != nil {
+				return "", weights, err
+			}
+		}
+		keys = append(keys, board)
+	}
+	keys = append(keys, trendingCreatedKey)
+	w := []float64{weights.Likes, weights.Comments, weights.Views, weights.Age}
+	return key, weights, uc.store.Union(ctx, key, keys, w, uc.opts.WindowCacheTTL)
+}
+
+func (uc *trendingUsecase) GetTrending(ctx context.Context, query TrendingQuery) ([]TrendingPost, error) {
+	var since time.Time
+	if query.Window != "" {
+		w, ok := trendingWindows[query.Window]
+		if !ok {
+			return nil, ErrInvalidWindow
+		}
+		since = time.Now().Add(-w.span)
+	}
+
+	var scores []repository.PostScore
+	switch query.Algo {
+	case "", AlgoRaw:
+		var err error
+		scores, err = uc.boardTopK(ctx, trendingPostsKey, query.Window, query.K)
+		if err != nil {
+			return nil, err
+		}
+	case AlgoViews:
+		var err error
+		scores, err = uc.boardTopK(ctx, trendingViewsKey, query.Window, query.K)
+		if err != nil {
+			return nil, err
+		}
+	case AlgoEngagement:
+		var err error
+		scores, err = uc.engagementTopK(ctx, query.Window, query.K)
+		if err != nil {
+			return nil, err
+		}
+	case AlgoDecay:
+		var err error
+		scores, err = uc.likeRepo.DecayedScores(ctx, since, uc.opts.HalfLife, uc.opts.Gravity, query.K)
+		if err != nil {
+			return nil, err
+		}
+	case AlgoSketch:
+		if query.Window != "" {
+			return nil, ErrWindowUnsupported
+		}
+		scores = sketchScores(uc.sketch.Top(query.K))
+	default:
+		return nil, ErrInvalidAlgo
+	}
+	return uc.hydrate(ctx, scores, query.ViewerID)
+}
+
+// GetRising scores each post active in the recent period by
+//
+//	(recent rate - baseline rate) / (baseline rate + 1)
+//
+// with rates in weighted engagement per minute, so a post doubling a busy
+// pace ranks with one going from nothing to a trickle, and the +1 keeps
+// posts without a baseline from scoring without bound.
+func (uc *trendingUsecase) GetRising(ctx context.Context, k int, viewerID uuid.UUID) ([]TrendingPost, error) {
+	opts := uc.opts.Rising
+	newest := time.Now().Truncate(risingBucket)
+	recentMinutes := int(opts.Recent / risingBucket)
+	baselineMinutes := int(opts.Baseline / risingBucket)
+
+	if err := uc.risingUnion(ctx, trendingRisingRecentKey, newest, recentMinutes); err != nil {
+		return nil, err
+	}
+	baselineEnd := newest.Add(-time.Duration(recentMinutes) * risingBucket)
+	if err := uc.risingUnion(ctx, trendingRisingBaselineKey, baselineEnd, baselineMinutes); err != nil {
+		return nil, err
+	}
+
+	recent, err := uc.store.TopK(ctx, trendingRisingRecentKey, risingCandidates)
+	if err != nil {
+		return nil, err
+	}
+	var scores []repository.PostScore
+	for _, m := range recent {
+		if m.Score < opts.MinActivity {
+			// TopK is sorted, so every remaining post is quieter still.
+			break
+		}
+		baseline, err := uc.store.Score(ctx, trendingRisingBaselineKey, m.Member)
+		if err != nil {
+			return nil, err
+		}
+		recentRate := m.Score / float64(recentMinutes)
+		baselineRate := baseline / float64(baselineMinutes)
+		velocity := (recentRate - baselineRate) / (baselineRate + 1)
+		if velocity <= 0 {
+			continue
+		}
+		id, err := uuid.Parse(m.Member)
+		if err != nil {
+			continue
+		}
+		scores = append(scores, repository.PostScore{PostID: id, Score: velocity})
+	}
+
+	sort.Slice(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	if len(scores) > k {
+		scores = scores[:k]
+	}
+	return uc.hydrate(ctx, scores, viewerID)
+}
+
+// risingUnion sums the weighted like, comment and view counts of the given
+// number of minute buckets, ending with the one starting at newest, into
+// dest unless a cached sum is still live.
+func (uc *trendingUsecase) risingUnion(ctx context.Context, dest string, newest time.Time, minutes int) error {
+	cached, err := uc.store.Exists(ctx, dest)
+	if err != nil || cached {
+		return err
+	}
+
+	weights := uc.engagementWeights()
+	boards := []struct {
+		key    string
+		weight float64
+	}{
+		{trendingPostsKey, weights.Likes},
+		{trendingCommentsKey, weights.Comments},
+		{trendingViewsKey, weights.Views},
+	}
+	keys := make([]string, 0, len(boards)*minutes)
+	w := make([]float64, 0, len(boards)*minutes)
+	for _, board := range boards {
+		for i := 0; i < minutes; i++ {
+			keys = append(keys, bucketKey(board.key, risingBucket, newest.Add(-time.Duration(i)*risingBucket)))
+			w = append(w, board.weight)
+		}
+	}
+	return uc.store.Union(ctx, dest, keys, w, uc.opts.WindowCacheTTL)
+}
+
+func (uc *trendingUsecase) GetTrendingAuthors(ctx context.Context, k int, window string) ([]TrendingAuthor, error) {
+	if _, ok := trendingWindows[window]; window != "" && !ok {
+		return nil, ErrInvalidWindow
+	}
+	members, err := uc.boardMembers(ctx, trendingAuthorsKey, window, k)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := postIDs(members)
+	users, err := uc.userRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]entity.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	authors := make([]TrendingAuthor, 0, len(members))
+	for _, m := range members {
+		id, err := uuid.Parse(m.Member)
+		if err != nil {
+			continue
+		}
+		user, ok := byID[id]
+		if !ok {
+			continue
+		}
+		authors = append(authors, TrendingAuthor{
+			Rank:  len(authors) + 1,
+			Score: m.Score,
+			Author: entity.UserInfo{
+				ID:            user.ID,
+				Username:      user.Username,
+				ProfilePicURL: user.ProfilePicURL,
+			},
+			Karma: user.Karma,
+		})
+	}
+	return authors, nil
+}
+
+func (uc *trendingUsecase) GetTrendingTags(ctx context.Context, k int, window string) ([]TrendingTag, error) {
+	if _, ok := trendingWindows[window]; window != "" && !ok {
+		return nil, ErrInvalidWindow
+	}
+	members, err := uc.boardMembers(ctx, trendingTagsKey, window, k)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]TrendingTag, len(members))
+	for i, m := range members {
+		tags[i] = TrendingTag{Rank: i + 1, Score: m.Score, Tag: m.Member}
+	}
+	return tags, nil
+}
+
+func (uc *trendingUsecase) GetTagPosts(ctx context.Context, tag string, k int, viewerID uuid.UUID) ([]TrendingPost, error) {
+	scores, err := uc.boardTopK(ctx, tagBoardKey(tag), "", k)
+	if err != nil {
+		return nil, err
+	}
+	return uc.hydrate(ctx, scores, viewerID)
+}
+
+func (uc *trendingUsecase) Explain(ctx context.Context, postID uuid.UUID) (*TrendingExplanation, error) {
+	post, err := uc.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPostNotFound, err)
+	}
+
+	now := time.Now()
+	explanation := &TrendingExplanation{
+		PostID:   postID,
+		AgeHours: now.Sub(post.CreatedAt).Hours(),
+		Weights:  uc.engagementWeights(),
+	}
+	for _, window := range append([]string{""}, windowNames()...) {
+		w, err := uc.explainWindow(ctx, post, window, now)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Windows = append(explanation.Windows, *w)
+	}
+	return explanation, nil
+}
+
+func (uc *trendingUsecase) explainWindow(ctx context.Context, post *entity.Post, window string, now time.Time) (*WindowExplanation, error) {
+	member := post.ID.String()
+	likesKey, commentsKey, viewsKey := trendingPostsKey, trendingCommentsKey, trendingViewsKey
+	var since time.Time
+	if window != "" {
+		w := trendingWindows[window]
+		// Windowed boards sum whole buckets, the oldest of which starts here.
+		since = now.Truncate(w.bucket).Add(-(w.span - w.bucket))
+		for _, key := range []*string{&likesKey, &commentsKey, &viewsKey} {
+			board, err := uc.windowBoard(ctx, *key, window)
+			if err != nil {
+				return nil, err
+			}
+			*key = board
+		}
+	}
+
+	explanation := &WindowExplanation{Window: window}
+	var err error
+	if explanation.Likes, err = uc.explainCount(ctx, likesKey, member, func() (float64, error) {
+		count, err := uc.likeRepo.CountByPostIDSince(ctx, post.ID, since)
+		if err != nil {
+			return 0, err
+		}
+		discount, err := uc.flagRepo.DiscountSince(ctx, post.ID, since)
+		return float64(count) - discount, err
+	}); err != nil {
+		return nil, err
+	}
+	if explanation.Comments, err = uc.explainCount(ctx, commentsKey, member, func() (float64, error) {
+		count, err := uc.commentRepo.CountByPostIDSince(ctx, post.ID, since)
+		return float64(count), err
+	}); err != nil {
+		return nil, err
+	}
+	if explanation.Views, err = uc.store.Score(ctx, viewsKey, member); err != nil {
+		return nil, err
+	}
+	if explanation.UniqueViews, err = uc.viewUC.UniqueViews(ctx, post.ID, window); err != nil {
+		return nil, err
+	}
+
+	engagementKey, weights, err := uc.engagementBoard(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	e := EngagementComponents{
+		Likes:    weights.Likes * explanation.Likes.Stored,
+		Comments: weights.Comments * explanation.Comments.Stored,
+		Views:    weights.Views * explanation.Views,
+		Age:      -weights.Age * now.Sub(post.CreatedAt).Hours(),
+	}
+	e.Total = e.Likes + e.Comments + e.Views + e.Age
+	explanation.Engagement = e
+
+	decayedLikes, err := uc.likeRepo.DecayedLikes(ctx, post.ID, since, uc.opts.HalfLife)
+	if err != nil {
+		return nil, err
+	}
+	gravityFactor := 1 / math.Pow(now.Sub(post.CreatedAt).Hours()+2, uc.opts.Gravity)
+	explanation.Decay = DecayComponents{
+		DecayedLikes:  decayedLikes,
+		GravityFactor: gravityFactor,
+		Score:         decayedLikes * gravityFactor,
+	}
+
+	for _, board := range []struct {
+		algo string
+		key  string
+	}{
+		{AlgoRaw, likesKey},
+		{AlgoViews, viewsKey},
+		{AlgoEngagement, engagementKey},
+	} {
+		ranking, err := uc.boardRanking(ctx, board.algo, board.key, member)
+		if err != nil {
+			return nil, err
+		}
+		if board.algo == AlgoEngagement {
+			// Undo the creation-hour term the same way engagementTopK does.
+			ranking.Score -= weights.Age * epochHours(now)
+		}
+		explanation.Rankings = append(explanation.Rankings, ranking)
+	}
+
+	decayRanking := AlgoRanking{Algo: AlgoDecay, Score: explanation.Decay.Score}
+	top, err := uc.likeRepo.DecayedScores(ctx, since, uc.opts.HalfLife, uc.opts.Gravity, explainRankDepth)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range top {
+		if s.PostID == post.ID {
+			decayRanking.Rank = int64(i + 1)
+			break
+		}
+	}
+	explanation.Rankings = append(explanation.Rankings, decayRanking)
+
+	if window == "" {
+		sketchRanking := AlgoRanking{Algo: AlgoSketch, Score: float64(uc.sketch.Estimate(member))}
+		for i, e := range uc.sketch.Top(explainRankDepth) {
+			if e.Item == member {
+				sketchRanking.Rank = int64(i + 1)
+				break
+			}
+		}
+		explanation.Rankings = append(explanation.Rankings, sketchRanking)
+	}
+	return explanation, nil
+}
+
+func (uc *trendingUsecase) explainCount(ctx context.Context, key, member string, count func() (float64, error)) (CountExplanation, error) {
+	stored, err := uc.store.Score(ctx, key, member)
+	if err != nil {
+		return CountExplanation{}, err
+	}
+	postgres, err := count()
+	if err != nil {
+		return CountExplanation{}, err
+	}
+	return CountExplanation{Stored: stored, Postgres: postgres, Drift: stored - postgres}, nil
+}
+
+func (uc *trendingUsecase) boardRanking(ctx context.Context, algo, key, member string) (AlgoRanking, error) {
+	score, err := uc.store.Score(ctx, key, member)
+	if err != nil {
+		return AlgoRanking{}, err
+	}
+	rank, _, err := uc.store.Rank(ctx, key, member)
+	if err != nil {
+		return AlgoRanking{}, err
+	}
+	return AlgoRanking{Algo: algo, Rank: rank, Score: score}, nil
+}
+
+// windowNames lists the trending windows from shortest to longest.
+func windowNames() []string {
+	names := make([]string, 0, len(trendingWindows))
+	for name := range trendingWindows {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return trendingWindows[names[i]].span < trendingWindows[names[j]].span
+	})
+	return names
+}
+
+// Rebuild recomputes the like, comment, author and tag boards with their
+// windowed buckets, the creation-time board and the sketch from Postgres,
+// taking the discounts of flagged likes off the like-based boards. Unique
+// views only live in the view store and are kept. Likes recorded while it
+// runs may be counted twice or not at all, so it belongs at startup, before
+// traffic is accepted.
+func (uc *trendingUsecase) Rebuild(ctx context.Context) error {
+	counts, err := uc.likeRepo.CountByPost(ctx)
+	if err != nil {
+		return err
+	}
+	if err := uc.rebuildBoard(ctx, trendingPostsKey, counts, uc.likeRepo.CountByBucket); err != nil {
+		return err
+	}
+	discounts, err := uc.discountLikes(ctx)
+	if err != nil {
+		return err
+	}
+	uc.sketch.Reset()
+	for _, c := range counts {
+		uc.sketch.Add(c.PostID.String(), c.Count-int64(math.Round(discounts[c.PostID])))
+	}
+
+	commentCounts, err := uc.commentRepo.CountByPost(ctx)
+	if err != nil {
+		return err
+	}
+	if err := uc.rebuildBoard(ctx, trendingCommentsKey, commentCounts, uc.commentRepo.CountByBucket); err != nil {
+		return err
+	}
+	if err := uc.rebuildAuthors(ctx); err != nil {
+		return err
+	}
+	if err := uc.rebuildTags(ctx); err != nil {
+		return err
+	}
+
+	created, err := uc.postRepo.CreationTimes(ctx)
+	if err != nil {
+		return err
+	}
+	if err := uc.store.Reset(ctx, trendingCreatedKey); err != nil {
+		return err
+	}
+	for id, at := range created {
+		if err := uc.store.Set(ctx, trendingCreatedKey, id.String(), epochHours(at)); err != nil {
+			return err
+		}
+	}
+	if err := uc.resetEngagement(ctx); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Rebuilt trending scores for %d posts", len(created))
+	return nil
+}
+
+// rebuildBoard replaces board with counts, and its buckets and cached windows
+// with what countByBucket reports.
+func (uc *trendingUsecase) rebuildBoard(
+	ctx context.Context,
+	board string,
+	counts []repository.PostCount,
+	countByBucket func(ctx context.Context, since time.Time, size time.Duration) ([]repository.BucketCount, error),
+) error {
+	if err := uc.resetBoard(ctx, board); err != nil {
+		return err
+	}
+	for _, c := range counts {
+		if err := uc.store.Incr(ctx, board, c.PostID.String(), float64(c.Count)); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for size, retention := range uc.retention {
+		buckets, err := countByBucket(ctx, now.Add(-retention).Truncate(size), size)
+		if err != nil {
+			return err
+		}
+		for _, b := range buckets {
+			key := bucketKey(board, size, b.Start)
+			if err := uc.store.Incr(ctx, key, b.PostID.String(), float64(b.Count)); err != nil {
+				return err
+			}
+			if err := uc.store.ExpireAt(ctx, key, b.Start.Add(size+retention)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rebuildAuthors replaces the author board and its buckets with the author
+// scores, net of flagged likes, recomputed from Postgres.
+func (uc *trendingUsecase) rebuildAuthors(ctx context.Context) error {
+	if err := uc.resetBoard(ctx, trendingAuthorsKey); err != nil {
+		return err
+	}
+	totals, err := uc.likeRepo.KarmaByAuthor(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range totals {
+		if err := uc.store.Incr(ctx, trendingAuthorsKey, t.AuthorID.String(), t.Score); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for size, retention := range uc.retention {
+		buckets, err := uc.likeRepo.KarmaByBucket(ctx, now.Add(-retention).Truncate(size), size)
+		if err != nil {
+			return err
+		}
+		for _, b := range buckets {
+			key := bucketKey(trendingAuthorsKey, size, b.Start)
+			if err := uc.store.Incr(ctx, key, b.AuthorID.String(), b.Score); err != nil {
+				return err
+			}
+			if err := uc.store.ExpireAt(ctx, key, b.Start.Add(size+retention)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rebuildTags replaces the board of every tag and the tag board with its
+// buckets from Postgres.
+func (uc *trendingUsecase) rebuildTags(ctx context.Context) error {
+	names, err := uc.tagRepo.Names(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := uc.store.Reset(ctx, tagBoardKey(name)); err != nil {
+			return err
+		}
+	}
+	if err := uc.resetBoard(ctx, trendingTagsKey); err != nil {
+		return err
+	}
+
+	scores, err := uc.tagRepo.LikesByTagPost(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range scores {
+		if err := uc.store.Incr(ctx, tagBoardKey(s.Tag), s.PostID.String(), s.Score); err != nil {
+			return err
+		}
+		if err := uc.store.Incr(ctx, trendingTagsKey, s.Tag, s.Score); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for size, retention := range uc.retention {
+		buckets, err := uc.tagRepo.LikesByBucket(ctx, now.Add(-retention).Truncate(size), size)
+		if err != nil {
+			return err
+		}
+		for _, b := range buckets {
+			key := bucketKey(trendingTagsKey, size, b.Start)
+			if err := uc.store.Incr(ctx, key, b.Tag, b.Score); err != nil {
+				return err
+			}
+			if err := uc.store.ExpireAt(ctx, key, b.Start.Add(size+retention)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resetBoard empties board, the buckets of it still within retention and its
+// cached windows.
+func (uc *trendingUsecase) resetBoard(ctx context.Context, board string) error {
+	if err := uc.store.Reset(ctx, board); err != nil {
+		return err
+	}
+	now := time.Now()
+	for size, retention := range uc.retention {
+		for start := now.Add(-retention).Truncate(size); !start.After(now); start = start.Add(size) {
+			if err := uc.store.Reset(ctx, bucketKey(board, size, start)); err != nil {
+				return err
+			}
+		}
+	}
+	for window := range trendingWindows {
+		if err := uc.store.Reset(ctx, windowKey(board, window)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discountLikes takes the discounts of flagged likes off the rebuilt like
+// board and its buckets, and returns the all-time discount of each post.
+func (uc *trendingUsecase) discountLikes(ctx context.Context) (map[uuid.UUID]float64, error) {
+	totals, err := uc.flagRepo.DiscountByPost(ctx)
+	if err != nil {
+		return nil, err
+	}
+	discounts := make(map[uuid.UUID]float64, len(totals))
+	for _, d := range totals {
+		discounts[d.PostID] = d.Score
+		if err := uc.store.Incr(ctx, trendingPostsKey, d.PostID.String(), -d.Score); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for size, retention := range uc.retention {
+		buckets, err := uc.flagRepo.DiscountByBucket(ctx, now.Add(-retention).Truncate(size), size)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range buckets {
+			key := bucketKey(trendingPostsKey, size, b.Start)
+			if err := uc.store.Incr(ctx, key, b.PostID.String(), -b.Score); err != nil {
+				return nil, err
+			}
+			if err := uc.store.ExpireAt(ctx, key, b.Start.Add(size+retention)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return discounts, nil
+}
+
+// DetectDrift compares the all-time score of a sample of posts against their
+// like count in Postgres, net of flagged likes, and repairs any mismatch. The
+// sample is the current top of the board, where drift is most visible, plus
+// random posts. It returns the number of posts repaired.
+func (uc *trendingUsecase) DetectDrift(ctx context.Context, sampleSize int) (int, error) {
+	top, err := uc.store.TopK(ctx, trendingPostsKey, sampleSize/2)
+	if err != nil {
+		return 0, err
+	}
+	random, err := uc.postRepo.SampleIDs(ctx, sampleSize-len(top))
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[uuid.UUID]bool, sampleSize)
+	ids := make([]uuid.UUID, 0, sampleSize)
+	for _, id := range append(postIDs(top), random...) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	before, err := uc.scores(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	counts, err := uc.likeRepo.CountByPostIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	discounts, err := uc.flagRepo.DiscountByPostIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	// Likes landing between the two reads change the stored score; only
+	// repair posts that held still so a live update is never mistaken for
+	// drift.
+	after, err := uc.scores(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	driftChecked.Add(int64(len(ids)))
+	repaired := 0
+	for i, id := range ids {
+		// Discounts are fractional, so sums of them are compared with a
+		// tolerance.
+		want := float64(counts[id]) - discounts[id]
+		if math.Abs(before[i]-want) < 1e-6 || before[i] != after[i] {
+			continue
+		}
+
+		driftFound.Add(1)
+		log.Printf("⚠️ Trending drift on post %s: stored %v, Postgres %v", id, after[i], want)
+		if err := uc.store.Incr(ctx, trendingPostsKey, id.String(), want-after[i]); err != nil {
+			return repaired, err
+		}
+		driftRepaired.Add(want - after[i])
+		repaired++
+	}
+	return repaired, nil
+}
+
+func (uc *trendingUsecase) scores(ctx context.Context, ids []uuid.UUID) ([]float64, error) {
+	scores := make([]float64, len(ids))
+	for i, id := range ids {
+		score, err := uc.store.Score(ctx, trendingPostsKey, id.String())
+		if err != nil {
+			return nil, err
+		}
+		scores[i] = score
+	}
+	return scores, nil
+}
+
+// RunDriftDetector runs DetectDrift every interval until ctx is done.
+func (uc *trendingUsecase) RunDriftDetector(ctx context.Context, interval time.Duration, sampleSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			repaired, err := uc.DetectDrift(ctx, sampleSize)
+			if err != nil {
+				log.Printf("⚠️ Trending drift check failed: %v", err)
+				continue
+			}
+			if repaired > 0 {
+				log.Printf("🔧 Repaired trending drift on %d posts", repaired)
+			}
+		}
+	}
+}
+
+// hydrate loads the posts behind a ranked list of scores, keeping the
+// ranking order and dropping posts that no longer exist.
+func (uc *trendingUsecase) hydrate(ctx context.Context, scores []repository.PostScore, viewerID uuid.UUID) ([]TrendingPost, error) {
+	ids := make([]uuid.UUID, len(scores))
+	for i, s := range scores {
+		ids[i] = s.PostID
+	}
+
+	posts, err := uc.postRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := uc.summaryUC.Summarize(ctx, posts, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]PostSummary, len(summaries))
+	for _, p := range summaries {
+		byID[p.ID] = p
+	}
+
+	trending := make([]TrendingPost, 0, len(scores))
+	for _, s := range scores {
+		post, ok := byID[s.PostID]
+		if !ok {
+			continue
+		}
+		trending = append(trending, TrendingPost{
+			Rank:  len(trending) + 1,
+			Score: s.Score,
+			Post:  post,
+		})
+	}
+	return trending, nil
+}
+
+func postScores(members []repository.ScoredMember) []repository.PostScore {
+	scores := make([]repository.PostScore, 0, len(members))
+	for _, m := range members {
+		id, err := uuid.Parse(m.Member)
+		if err != nil {
+			continue
+		}
+		scores = append(scores, repository.PostScore{PostID: id, Score: m.Score})
+	}
+	return scores
+}
+
+func postIDs(members []repository.ScoredMember) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		if id, err := uuid.Parse(m.Member); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func sketchScores(entries []topk.Entry) []repository.PostScore {
+	scores := make([]repository.PostScore, 0, len(entries))
+	for _, e := range entries {
+		id, err := uuid.Parse(e.Item)
+		if err != nil {
+			continue
+		}
+		scores = append(scores, repository.PostScore{PostID: id, Score: float64(e.Count)})
+	}
+	return scores
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"backend/internal/entity"
+	"backend/internal/event"
 	"backend/internal/repository"
 	"backend/pkg/hash"
 	"backend/pkg/jwt"
@@ -22,13 +23,17 @@ type userUsecase struct {
 	userRepo    repository.UserRepository
 	jwtService  jwt.JWTService
 	timeout     time.Duration
+	tx          repository.Transactor
+	events      event.Publisher
 }
 
-func NewUserUsecase(userRepo repository.UserRepository, jwtService jwt.JWTService, timeout time.Duration) UserUsecase {
+func NewUserUsecase(userRepo repository.UserRepository, jwtService jwt.JWTService, timeout time.Duration, tx repository.Transactor, events event.Publisher) UserUsecase {
 	return &userUsecase{
 		userRepo:   userRepo,
 		jwtService: jwtService,
 		timeout:    timeout,
+		tx:         tx,
+		events:     events,
 	}
 }
 
@@ -49,11 +54,20 @@ func (uc *userUsecase) Register(ctx context.Context, user *entity.User, rawPassw
 		user.ID = uuid.New()
 	}
 
-	return uc.userRepo.Create(user)
+	return uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return uc.events.Publish(ctx, event.UserRegistered{
+			UserID:   user.ID,
+			Username: user.Username,
+			At:       user.CreatedAt,
+		})
+	})
 }
 
 func (uc *userUsecase) Login(ctx context.Context, email, password string) (*LoginResponse, error) {
-	user, err := uc.userRepo.FindByEmail(email)
+	user, err := uc.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, errors.New("invalid credentials")
 	}
@@ -83,5 +97,5 @@ func (uc *userUsecase) GetProfile(ctx context.Context, id string) (*entity.User,
 	if err != nil {
 		return nil, err
 	}
-	return uc.userRepo.FindByID(ID)
+	return uc.userRepo.FindByID(ctx, ID)
 }
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"backend/internal/entity"
+	"backend/internal/event"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ViewUsecase interface {
+	// RecordView counts viewer on the post. viewer identifies a user or an
+	// anonymous reader and is only ever hashed into the counters.
+	RecordView(ctx context.Context, postID uuid.UUID, viewer string) error
+	// UniqueViews estimates the distinct viewers of the post, all-time when
+	// window is empty.
+	UniqueViews(ctx context.Context, postID uuid.UUID, window string) (int64, error)
+	// FillUniqueViews sets the all-time unique views of each post.
+	FillUniqueViews(ctx context.Context, posts []entity.Post) error
+	RemovePost(ctx context.Context, postID uuid.UUID) error
+}
+
+type viewUsecase struct {
+	store  repository.ViewStore
+	events event.Publisher
+}
+
+// NewViewUsecase publishes PostViewed straight to events rather than
+// through the outbox: views are high-volume and losing one is harmless.
+func NewViewUsecase(store repository.ViewStore, events event.Publisher) ViewUsecase {
+	return &viewUsecase{
+		store:  store,
+		events: events,
+	}
+}
+
+func viewsKey(postID uuid.UUID) string {
+	return fmt.Sprintf("views:post:%s", postID)
+}
+
+func (uc *viewUsecase) RecordView(ctx context.Context, postID uuid.UUID, viewer string) error {
+	now := time.Now()
+	key := viewsKey(postID)
+
+	added, err := uc.store.Add(ctx, key, viewer)
+	if err != nil {
+		return err
+	}
+	for size, retention := range bucketRetention {
+		start := now.Truncate(size)
+		bucket := bucketKey(key, size, start)
+		if _, err := uc.store.Add(ctx, bucket, viewer); err != nil {
+			return err
+		}
+		if err := uc.store.ExpireAt(ctx, bucket, start.Add(size+retention)); err != nil {
+			return err
+		}
+	}
+
+	if added {
+		if err := uc.events.Publish(ctx, event.PostViewed{PostID: postID, At: now}); err != nil {
+			log.Printf("⚠️ Failed to publish view of post %s: %v", postID, err)
+		}
+	}
+	return nil
+}
+
+// UniqueViews counts a window over the union of its buckets, so a viewer
+// seen in several buckets is still counted once.
+func (uc *viewUsecase) UniqueViews(ctx context.Context, postID uuid.UUID, window string) (int64, error) {
+	key := viewsKey(postID)
+	if window == "" {
+		return uc.store.Count(ctx, key)
+	}
+
+	w, ok := trendingWindows[window]
+	if !ok {
+		return 0, ErrInvalidWindow
+	}
+	n := int(w.span / w.bucket)
+	newest := time.Now().Truncate(w.bucket)
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = bucketKey(key, w.bucket, newest.Add(-time.Duration(i)*w.bucket))
+	}
+	return uc.store.Count(ctx, keys...)
+}
+
+func (uc *viewUsecase) FillUniqueViews(ctx context.Context, posts []entity.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	keys := make([]string, len(posts))
+	for i, p := range posts {
+		keys[i] = viewsKey(p.ID)
+	}
+	counts, err := uc.store.CountEach(ctx, keys)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].UniqueViews = counts[i]
+	}
+	return nil
+}
+
+// RemovePost drops the all-time counter of a deleted post. Bucket counters
+// expire on their own.
+func (uc *viewUsecase) RemovePost(ctx context.Context, postID uuid.UUID) error {
+	return uc.store.Delete(ctx, viewsKey(postID))
+}
@@ -0,0 +1,62 @@
+// Package hashtag finds #tags in free text.
+package hashtag
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength is the longest tag kept, in characters. Longer runs of tag
+	// characters are ignored rather than cut.
+	MaxLength = 50
+	// MaxPerText caps how many tags Parse returns.
+	MaxPerText = 10
+)
+
+// A tag starts at the beginning of the text or after a character that cannot
+// be part of a word or URL, so "a#b" and "example.com/#top" are not tags.
+var tagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]+)`)
+
+// Parse returns the distinct tags in texts, normalized and in order of first
+// appearance, up to MaxPerText.
+func Parse(texts ...string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, text := range texts {
+		for _, m := range tagPattern.FindAllStringSubmatch(text, -1) {
+			tag, ok := Normalize(m[1])
+			if !ok || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+			if len(tags) == MaxPerText {
+				return tags
+			}
+		}
+	}
+	return tags
+}
+
+// Normalize lowercases a tag, with or without its leading #, and reports
+// whether it is valid: 1 to MaxLength letters, digits or underscores, not
+// all of them digits.
+func Normalize(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxLength {
+		return "", false
+	}
+	digits := true
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_' {
+			return "", false
+		}
+		if !unicode.IsNumber(r) {
+			digits = false
+		}
+	}
+	return tag, !digits
+}
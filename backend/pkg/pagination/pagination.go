@@ -0,0 +1,152 @@
+// Package pagination pages through lists ordered by (created_at, id), newest
+// first unless stated otherwise and optionally led by another value, with
+// opaque keyset cursors. A cursor points between two rows rather than at an
+// offset, so rows inserted while a client pages do not shift the pages it has
+// yet to read.
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the key of the row a page starts after. Backward cursors read
+// the rows before it instead of the ones after it.
+//
+// Lists ordered by something other than creation time name their order in
+// Sort and keep the row's count leading it in Value; At is the time an
+// order that changes with time was taken as of.
+type Cursor struct {
+	Sort      string    `json:"s,omitempty"`
+	Value     int64     `json:"v,omitempty"`
+	At        time.Time `json:"at,omitzero"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func Decode(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Params selects a page. A nil Cursor is the first page.
+type Params struct {
+	Limit  int
+	Cursor *Cursor
+}
+
+// Info is sent in the response's Meta.Pagination. An empty cursor means
+// there is nothing more in that direction.
+type Info struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Key returns the position of a row as a forward cursor.
+type Key[T any] func(row T) Cursor
+
+// Page turns rows fetched for p, in fetch order and up to p.Limit+1 of
+// them, into the page, in list order, and its cursors. Fetching one row
+// more than the limit tells whether another page follows in the direction
+// read.
+func Page[T any](rows []T, p Params, key Key[T]) ([]T, Info) {
+	backward := p.Cursor != nil && p.Cursor.Backward
+	more := len(rows) > p.Limit
+	if more {
+		rows = rows[:p.Limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	info := Info{Limit: p.Limit}
+	if len(rows) == 0 {
+		return rows, info
+	}
+	// Going forward there are earlier rows whenever a cursor was followed;
+	// going backward there are later ones, the page the cursor came from.
+	hasNext, hasPrev := more, p.Cursor != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		info.NextCursor = key(rows[len(rows)-1]).Encode()
+	}
+	if hasPrev {
+		prev := key(rows[0])
+		prev.Backward = true
+		info.PrevCursor = prev.Encode()
+	}
+	return rows, info
+}
+
+// Apply pages through rows held in memory, in any order, newest first.
+func Apply[T any](rows []T, p Params, key Key[T]) ([]T, Info) {
+	var picked []T
+	for _, row := range rows {
+		if p.Cursor == nil {
+			picked = append(picked, row)
+			continue
+		}
+		k := key(row)
+		c := compare(k.CreatedAt, k.ID, p.Cursor.CreatedAt, p.Cursor.ID)
+		if c != 0 && (c < 0) != p.Cursor.Backward {
+			picked = append(picked, row)
+		}
+	}
+
+	// Fetch order: newest first going forward, oldest first going backward.
+	backward := p.Cursor != nil && p.Cursor.Backward
+	sort.Slice(picked, func(i, j int) bool {
+		ki, kj := key(picked[i]), key(picked[j])
+		c := compare(ki.CreatedAt, ki.ID, kj.CreatedAt, kj.ID)
+		if backward {
+			return c < 0
+		}
+		return c > 0
+	})
+	if len(picked) > p.Limit+1 {
+		picked = picked[:p.Limit+1]
+	}
+	return Page(picked, p, key)
+}
+
+// compare orders keys the way Postgres orders (timestamptz, uuid) rows.
+func compare(at1 time.Time, id1 uuid.UUID, at2 time.Time, id2 uuid.UUID) int {
+	if at1.Before(at2) {
+		return -1
+	}
+	if at1.After(at2) {
+		return 1
+	}
+	return bytes.Compare(id1[:], id2[:])
+}
@@ -0,0 +1,207 @@
+package topk
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// CountMinSketch estimates counts within epsilon*N of the truth with
+// probability 1-delta, where N is the total weight added. Because the sketch
+// itself cannot enumerate items, it also keeps the ceil(1/epsilon) items with
+// the highest estimates seen so far as top-k candidates.
+//
+// Decrements are subtracted from the counters, so estimates stay upper bounds
+// only while no item's net count goes below zero; past that the minimum can
+// underestimate. The candidates are chosen as items are added, so an item
+// that only overtakes them through other items' decrements is missed.
+type CountMinSketch struct {
+	mu     sync.Mutex
+	width  int
+	depth  int
+	counts [][]int64
+	// candidates holds the tracked items in a min-heap by estimate. An
+	// item's estimate grows whenever another item shares a counter with it,
+	// so the heap keys are refreshed lazily when the minimum is needed.
+	candidates map[string]*ssCounter
+	heap       ssHeap
+	capacity   int
+}
+
+func NewCountMinSketch(epsilon, delta float64) (*CountMinSketch, error) {
+	capacity, err := capacityFor(epsilon)
+	if err != nil {
+		return nil, err
+	}
+	if delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("topk: delta must be in (0, 1), got %v", delta)
+	}
+
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	counts := make([][]int64, depth)
+	for i := range counts {
+		counts[i] = make([]int64, width)
+	}
+	return &CountMinSketch{
+		width:      width,
+		depth:      depth,
+		counts:     counts,
+		candidates: make(map[string]*ssCounter, capacity),
+		capacity:   capacity,
+	}, nil
+}
+
+// indexes derives one column per row by running splitmix64 from the item's
+// FNV-1a hash. FNV's low bits alone are close for similar items, and a sum
+// of two hashes wraps before the modulo, so rows would collide together.
+func (s *CountMinSketch) indexes(item string) []int {
+	h := fnv.New64a()
+	h.Write([]byte(item))
+	state := h.Sum64()
+
+	idx := make([]int, s.depth)
+	for i := range idx {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		z ^= z >> 31
+		idx[i] = int(z % uint64(s.width))
+	}
+	return idx
+}
+
+func (s *CountMinSketch) Add(item string, count int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for row, col := range s.indexes(item) {
+		s.counts[row][col] += count
+	}
+	if _, ok := s.candidates[item]; ok || count > 0 {
+		s.track(item)
+	}
+}
+
+// track keeps item among the candidates if its estimate beats the weakest one.
+func (s *CountMinSketch) track(item string) {
+	est := s.estimate(item)
+	if c, ok := s.candidates[item]; ok {
+		c.count = est
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.candidates) < s.capacity {
+		c := &ssCounter{item: item, count: est}
+		s.candidates[item] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+
+	// Refresh the minimum until its key is current. Other keys only lag
+	// estimates that have since grown, so the minimum found is the weakest.
+	for {
+		weakest := s.heap[0]
+		e := s.estimate(weakest.item)
+		if e == weakest.count {
+			break
+		}
+		weakest.count = e
+		heap.Fix(&s.heap, 0)
+	}
+	if weakest := s.heap[0]; est > weakest.count {
+		delete(s.candidates, weakest.item)
+		weakest.item, weakest.count = item, est
+		s.candidates[item] = weakest
+		heap.Fix(&s.heap, 0)
+	}
+}
+
+func (s *CountMinSketch) Estimate(item string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.estimate(item)
+}
+
+func (s *CountMinSketch) estimate(item string) int64 {
+	est := int64(math.MaxInt64)
+	for row, col := range s.indexes(item) {
+		if c := s.counts[row][col]; c < est {
+			est = c
+		}
+	}
+	return est
+}
+
+func (s *CountMinSketch) Top(k int) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bound := s.errorBound()
+	entries := make([]Entry, 0, len(s.candidates))
+	for c := range s.candidates {
+		if est := s.estimate(c); est > 0 {
+			entries = append(entries, Entry{Item: c, Count: est, Error: bound})
+		}
+	}
+	return sortEntries(entries, k)
+}
+
+// errorBound is epsilon*N, using the first row's total as N.
+func (s *CountMinSketch) errorBound() int64 {
+	var total int64
+	for _, c := range s.counts[0] {
+		total += c
+	}
+	return int64(math.Ceil(math.E / float64(s.width) * float64(total)))
+}
+
+func (s *CountMinSketch) Merge(other TopK) error {
+	o, ok := other.(*CountMinSketch)
+	if !ok || o == s {
+		return ErrIncompatible
+	}
+
+	o.mu.Lock()
+	if o.width != s.width || o.depth != s.depth {
+		o.mu.Unlock()
+		return ErrIncompatible
+	}
+	counts := make([][]int64, o.depth)
+	for i := range counts {
+		counts[i] = append([]int64(nil), o.counts[i]...)
+	}
+	candidates := make([]string, 0, len(o.candidates))
+	for c := range o.candidates {
+		candidates = append(candidates, c)
+	}
+	o.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for row := range s.counts {
+		for col := range s.counts[row] {
+			s.counts[row][col] += counts[row][col]
+		}
+	}
+	for _, c := range candidates {
+		s.track(c)
+	}
+	return nil
+}
+
+func (s *CountMinSketch) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for row := range s.counts {
+		for col := range s.counts[row] {
+			s.counts[row][col] = 0
+		}
+	}
+	s.candidates = make(map[string]*ssCounter, s.capacity)
+	s.heap = nil
+}
@@ -0,0 +1,146 @@
+package topk
+
+import (
+	"sort"
+	"sync"
+)
+
+// MisraGries keeps ceil(1/epsilon) counters. Every reported count is an
+// underestimate by at most epsilon*N, and any item occurring more than
+// epsilon*N times is guaranteed to be tracked.
+type MisraGries struct {
+	mu       sync.Mutex
+	capacity int
+	counts   map[string]int64
+	// decremented is the total weight removed from every counter, which
+	// bounds the underestimate of each reported count.
+	decremented int64
+}
+
+func NewMisraGries(epsilon float64) (*MisraGries, error) {
+	capacity, err := capacityFor(epsilon)
+	if err != nil {
+		return nil, err
+	}
+	return &MisraGries{
+		capacity: capacity,
+		counts:   make(map[string]int64, capacity),
+	}, nil
+}
+
+func (m *MisraGries) Add(item string, count int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if count < 0 {
+		if c, ok := m.counts[item]; ok {
+			if c+count > 0 {
+				m.counts[item] = c + count
+			} else {
+				delete(m.counts, item)
+			}
+		}
+		return
+	}
+
+	if _, ok := m.counts[item]; ok || len(m.counts) < m.capacity {
+		m.counts[item] += count
+		return
+	}
+
+	// Weighted decrement step: cancel the new item against every counter
+	// until either it is used up or a counter frees up for it.
+	for count > 0 && len(m.counts) >= m.capacity {
+		d := count
+		for _, c := range m.counts {
+			if c < d {
+				d = c
+			}
+		}
+		for it, c := range m.counts {
+			if c == d {
+				delete(m.counts, it)
+			} else {
+				m.counts[it] = c - d
+			}
+		}
+		m.decremented += d
+		count -= d
+	}
+	if count > 0 {
+		m.counts[item] = count
+	}
+}
+
+func (m *MisraGries) Estimate(item string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[item]
+}
+
+func (m *MisraGries) Top(k int) []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]Entry, 0, len(m.counts))
+	for it, c := range m.counts {
+		entries = append(entries, Entry{Item: it, Count: c, Error: m.decremented})
+	}
+	return sortEntries(entries, k)
+}
+
+// Merge follows Agarwal et al.'s mergeable summaries: add the counters, then
+// subtract the (capacity+1)-th largest count from all of them.
+func (m *MisraGries) Merge(other TopK) error {
+	o, ok := other.(*MisraGries)
+	if !ok || o == m {
+		return ErrIncompatible
+	}
+
+	o.mu.Lock()
+	if o.capacity != m.capacity {
+		o.mu.Unlock()
+		return ErrIncompatible
+	}
+	counts := make(map[string]int64, len(o.counts))
+	for it, c := range o.counts {
+		counts[it] = c
+	}
+	decremented := o.decremented
+	o.mu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for it, c := range counts {
+		m.counts[it] += c
+	}
+	m.decremented += decremented
+	if len(m.counts) <= m.capacity {
+		return nil
+	}
+
+	values := make([]int64, 0, len(m.counts))
+	for _, c := range m.counts {
+		values = append(values, c)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] > values[j] })
+	cut := values[m.capacity]
+	for it, c := range m.counts {
+		if c <= cut {
+			delete(m.counts, it)
+		} else {
+			m.counts[it] = c - cut
+		}
+	}
+	m.decremented += cut
+	return nil
+}
+
+func (m *MisraGries) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counts = make(map[string]int64, m.capacity)
+	m.decremented = 0
+}
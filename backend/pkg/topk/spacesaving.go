@@ -0,0 +1,191 @@
+package topk
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// SpaceSaving keeps ceil(1/epsilon) counters. When a new item arrives and all
+// counters are taken, it evicts the smallest counter and inherits its count,
+// so every reported count overestimates by at most epsilon*N.
+type SpaceSaving struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*ssCounter
+	heap     ssHeap
+}
+
+type ssCounter struct {
+	item  string
+	count int64
+	err   int64
+	index int
+}
+
+// ssHeap is a min-heap of counters ordered by count.
+type ssHeap []*ssCounter
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *ssHeap) Push(x any) {
+	c := x.(*ssCounter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+func (h *ssHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+func NewSpaceSaving(epsilon float64) (*SpaceSaving, error) {
+	capacity, err := capacityFor(epsilon)
+	if err != nil {
+		return nil, err
+	}
+	return &SpaceSaving{
+		capacity: capacity,
+		items:    make(map[string]*ssCounter, capacity),
+	}, nil
+}
+
+func (s *SpaceSaving) Add(item string, count int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(item, count, 0)
+}
+
+func (s *SpaceSaving) add(item string, count, err int64) {
+	if c, ok := s.items[item]; ok {
+		c.count += count
+		c.err += err
+		if c.count <= 0 {
+			heap.Remove(&s.heap, c.index)
+			delete(s.items, item)
+			return
+		}
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if count <= 0 {
+		return
+	}
+
+	if len(s.items) < s.capacity {
+		c := &ssCounter{item: item, count: count, err: err}
+		s.items[item] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+
+	// Replace the minimum: the newcomer may have occurred up to min times
+	// before, which becomes its error.
+	min := s.heap[0]
+	delete(s.items, min.item)
+	min.err = min.count + err
+	min.count += count
+	min.item = item
+	s.items[item] = min
+	heap.Fix(&s.heap, 0)
+}
+
+func (s *SpaceSaving) Estimate(item string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.items[item]; ok {
+		return c.count
+	}
+	if len(s.items) == s.capacity {
+		// An untracked item can have occurred at most min times.
+		return s.heap[0].count
+	}
+	return 0
+}
+
+func (s *SpaceSaving) Top(k int) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.items))
+	for _, c := range s.items {
+		entries = append(entries, Entry{Item: c.item, Count: c.count, Error: c.err})
+	}
+	return sortEntries(entries, k)
+}
+
+// Merge adds other's counters into the receiver. An item missing from a full
+// summary is charged that summary's minimum as both count and error, which
+// keeps the overestimate guarantee (Cafaro et al.).
+func (s *SpaceSaving) Merge(other TopK) error {
+	o, ok := other.(*SpaceSaving)
+	if !ok || o == s {
+		return ErrIncompatible
+	}
+
+	o.mu.Lock()
+	if o.capacity != s.capacity {
+		o.mu.Unlock()
+		return ErrIncompatible
+	}
+	theirs := make([]ssCounter, 0, len(o.items))
+	for _, c := range o.items {
+		theirs = append(theirs, *c)
+	}
+	var theirMin int64
+	if len(o.items) == o.capacity {
+		theirMin = o.heap[0].count
+	}
+	o.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ourMin int64
+	if len(s.items) == s.capacity {
+		ourMin = s.heap[0].count
+	}
+
+	merged := make(map[string]ssCounter, len(s.items)+len(theirs))
+	for _, c := range s.items {
+		merged[c.item] = ssCounter{item: c.item, count: c.count + theirMin, err: c.err + theirMin}
+	}
+	for _, c := range theirs {
+		if m, ok := merged[c.item]; ok {
+			m.count += c.count - theirMin
+			m.err += c.err - theirMin
+			merged[c.item] = m
+		} else {
+			merged[c.item] = ssCounter{item: c.item, count: c.count + ourMin, err: c.err + ourMin}
+		}
+	}
+
+	entries := make([]Entry, 0, len(merged))
+	for _, c := range merged {
+		entries = append(entries, Entry{Item: c.item, Count: c.count, Error: c.err})
+	}
+	entries = sortEntries(entries, s.capacity)
+
+	s.items = make(map[string]*ssCounter, s.capacity)
+	s.heap = s.heap[:0]
+	for _, e := range entries {
+		c := &ssCounter{item: e.Item, count: e.Count, err: e.Error}
+		s.items[e.Item] = c
+		heap.Push(&s.heap, c)
+	}
+	return nil
+}
+
+func (s *SpaceSaving) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]*ssCounter, s.capacity)
+	s.heap = nil
+}
@@ -0,0 +1,83 @@
+// Package topk implements streaming heavy-hitter summaries that track the most
+// frequent items in bounded memory.
+//
+// All summaries accept negative counts so that an unlike can cancel a like,
+// as a best-effort extension outside their usual insert-only guarantees.
+// Count-Min Sketch keeps its bounds only while no item's net count goes below
+// zero, and its top-k candidates may miss an item that rises through others'
+// decrements; Misra-Gries and Space-Saving only decrement items they are
+// currently tracking.
+package topk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	AlgoCountMin    = "countmin"
+	AlgoMisraGries  = "misragries"
+	AlgoSpaceSaving = "spacesaving"
+)
+
+var ErrIncompatible = errors.New("topk: summaries have different types or parameters")
+
+// Entry is an item with its estimated count. Error bounds how far Count may be
+// off from the true count: an overestimate for Count-Min Sketch and
+// Space-Saving, an underestimate for Misra-Gries.
+type Entry struct {
+	Item  string
+	Count int64
+	Error int64
+}
+
+type TopK interface {
+	Add(item string, count int64)
+	Estimate(item string) int64
+	Top(k int) []Entry
+	// Merge folds other into the receiver. Both must be the same kind of
+	// summary built with the same parameters.
+	Merge(other TopK) error
+	Reset()
+}
+
+// New builds a summary by name. epsilon bounds the error relative to the
+// total stream weight; delta is the Count-Min failure probability and is
+// ignored by the counter-based summaries.
+func New(algo string, epsilon, delta float64) (TopK, error) {
+	switch algo {
+	case AlgoCountMin:
+		return NewCountMinSketch(epsilon, delta)
+	case AlgoMisraGries:
+		return NewMisraGries(epsilon)
+	case AlgoSpaceSaving:
+		return NewSpaceSaving(epsilon)
+	default:
+		return nil, fmt.Errorf("topk: unknown algorithm %q", algo)
+	}
+}
+
+// capacityFor is the number of counters needed for an epsilon error bound.
+func capacityFor(epsilon float64) (int, error) {
+	if epsilon <= 0 || epsilon >= 1 {
+		return 0, fmt.Errorf("topk: epsilon must be in (0, 1), got %v", epsilon)
+	}
+	return int(math.Ceil(1 / epsilon)), nil
+}
+
+// sortEntries orders entries by descending count, breaking ties by item so
+// results are deterministic, and truncates to k.
+func sortEntries(entries []Entry, k int) []Entry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Item < entries[j].Item
+	})
+	if k >= 0 && len(entries) > k {
+		entries = entries[:k]
+	}
+	return entries
+}
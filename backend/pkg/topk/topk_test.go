@@ -0,0 +1,235 @@
+package topk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const (
+	testEpsilon = 0.01
+	testDelta   = 0.001
+)
+
+// bound says which side of the true count a summary's estimates fall on.
+type bound int
+
+const (
+	over bound = iota
+	under
+)
+
+var algos = []struct {
+	name  string
+	bound bound
+}{
+	{AlgoCountMin, over},
+	{AlgoMisraGries, under},
+	{AlgoSpaceSaving, over},
+}
+
+// stream is a skewed, reproducible sequence of items, one unit each.
+func stream(seed int64, n int) []string {
+	rng := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(rng, 1.2, 1, 999)
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", zipf.Uint64())
+	}
+	return items
+}
+
+func counts(items ...[]string) map[string]int64 {
+	truth := make(map[string]int64)
+	for _, s := range items {
+		for _, it := range s {
+			truth[it]++
+		}
+	}
+	return truth
+}
+
+func build(t *testing.T, algo string, items []string) TopK {
+	t.Helper()
+	s, err := New(algo, testEpsilon, testDelta)
+	if err != nil {
+		t.Fatalf("New(%q): %v", algo, err)
+	}
+	for _, it := range items {
+		s.Add(it, 1)
+	}
+	return s
+}
+
+// checkBounds checks every reported entry against the truth: on the
+// summary's side of it, off by no more than its Error, with Error within
+// epsilon*n. It also checks every item heavier than epsilon*n is reported.
+func checkBounds(t *testing.T, s TopK, b bound, truth map[string]int64, n int) {
+	t.Helper()
+	limit := int64(math.Ceil(testEpsilon*float64(n))) + 1
+	reported := make(map[string]bool)
+	for _, e := range s.Top(-1) {
+		reported[e.Item] = true
+		diff := e.Count - truth[e.Item]
+		if b == under {
+			diff = -diff
+		}
+		if diff < 0 || diff > e.Error {
+			t.Errorf("%s: count %d, true %d, error %d", e.Item, e.Count, truth[e.Item], e.Error)
+		}
+		if e.Error > limit {
+			t.Errorf("%s: error %d exceeds epsilon*N = %d", e.Item, e.Error, limit)
+		}
+	}
+	for it, c := range truth {
+		if float64(c) > testEpsilon*float64(n) && !reported[it] {
+			t.Errorf("%s occurs %d times but is not reported", it, c)
+		}
+	}
+}
+
+func TestAddWithinBounds(t *testing.T) {
+	tests := []struct {
+		seed int64
+		n    int
+	}{
+		{seed: 1, n: 1000},
+		{seed: 2, n: 20000},
+		{seed: 3, n: 50000},
+	}
+	for _, a := range algos {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%d", a.name, tt.n), func(t *testing.T) {
+				items := stream(tt.seed, tt.n)
+				checkBounds(t, build(t, a.name, items), a.bound, counts(items), tt.n)
+			})
+		}
+	}
+}
+
+func TestMergeWithinBounds(t *testing.T) {
+	for _, a := range algos {
+		t.Run(a.name, func(t *testing.T) {
+			left, right := stream(4, 20000), stream(5, 30000)
+			s := build(t, a.name, left)
+			if err := s.Merge(build(t, a.name, right)); err != nil {
+				t.Fatalf("Merge: %v", err)
+			}
+			checkBounds(t, s, a.bound, counts(left, right), len(left)+len(right))
+		})
+	}
+}
+
+func TestMergeIncompatible(t *testing.T) {
+	cm, _ := NewCountMinSketch(testEpsilon, testDelta)
+	cmWide, _ := NewCountMinSketch(testEpsilon/2, testDelta)
+	mg, _ := NewMisraGries(testEpsilon)
+	mgWide, _ := NewMisraGries(testEpsilon / 2)
+	ss, _ := NewSpaceSaving(testEpsilon)
+	ssWide, _ := NewSpaceSaving(testEpsilon / 2)
+
+	tests := []struct {
+		name        string
+		into, other TopK
+	}{
+		{"countmin/type", cm, ss},
+		{"countmin/size", cm, cmWide},
+		{"countmin/self", cm, cm},
+		{"misragries/type", mg, cm},
+		{"misragries/size", mg, mgWide},
+		{"misragries/self", mg, mg},
+		{"spacesaving/type", ss, mg},
+		{"spacesaving/size", ss, ssWide},
+		{"spacesaving/self", ss, ss},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.into.Merge(tt.other); !errors.Is(err, ErrIncompatible) {
+				t.Errorf("Merge = %v, want ErrIncompatible", err)
+			}
+		})
+	}
+}
+
+func TestTopOrder(t *testing.T) {
+	for _, a := range algos {
+		t.Run(a.name, func(t *testing.T) {
+			s, err := New(a.name, testEpsilon, testDelta)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			for _, add := range []struct {
+				item  string
+				count int64
+			}{{"a", 5}, {"b", 9}, {"c", 5}, {"d", 1}} {
+				s.Add(add.item, add.count)
+			}
+
+			got := s.Top(3)
+			want := []string{"b", "a", "c"}
+			if len(got) != len(want) {
+				t.Fatalf("Top(3) = %v, want items %v", got, want)
+			}
+			for i, e := range got {
+				if e.Item != want[i] {
+					t.Errorf("Top(3)[%d] = %s, want %s", i, e.Item, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecrementCancels(t *testing.T) {
+	for _, a := range algos {
+		t.Run(a.name, func(t *testing.T) {
+			s, err := New(a.name, testEpsilon, testDelta)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			s.Add("kept", 3)
+			s.Add("unliked", 4)
+			s.Add("unliked", -4)
+
+			top := s.Top(-1)
+			if len(top) != 1 || top[0].Item != "kept" || top[0].Count != 3 {
+				t.Errorf("Top = %v, want only kept with 3", top)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	for _, a := range algos {
+		t.Run(a.name, func(t *testing.T) {
+			s := build(t, a.name, stream(6, 5000))
+			s.Reset()
+			if top := s.Top(-1); len(top) != 0 {
+				t.Errorf("Top after Reset = %v, want none", top)
+			}
+			s.Add("x", 2)
+			if got := s.Estimate("x"); got != 2 {
+				t.Errorf("Estimate after Reset = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestNewRejectsParameters(t *testing.T) {
+	tests := []struct {
+		algo           string
+		epsilon, delta float64
+	}{
+		{AlgoSpaceSaving, 0, testDelta},
+		{AlgoMisraGries, 1, testDelta},
+		{AlgoCountMin, testEpsilon, 0},
+		{AlgoCountMin, testEpsilon, 1},
+		{"lossy", testEpsilon, testDelta},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.algo, tt.epsilon, tt.delta); err == nil {
+			t.Errorf("New(%q, %v, %v) succeeded", tt.algo, tt.epsilon, tt.delta)
+		}
+	}
+}